@@ -0,0 +1,170 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Stop is returned by NextBackOff once the context of the backoff is done,
+// no more attempts should be made
+const Stop time.Duration = -1
+
+// ErrInvalidRange is returned for backoff with min not positive or greater than max
+var ErrInvalidRange = errors.New("backoff min must be positive and not greater than max")
+
+// Clock provides time to Backoff, it can be replaced in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is Clock backed by time package
+type SystemClock struct{}
+
+// Now returns current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Backoff is exponential backoff with full jitter. Every call to NextBackOff
+// returns random duration between zero and min * multiplier^attempt capped at max.
+// It implements BackOff and BackOffContext interfaces used by the sse client.
+type Backoff struct {
+	min        time.Duration
+	max        time.Duration
+	multiplier float64
+	clock      Clock
+	ctx        context.Context
+	mux        sync.Mutex
+	attempt    int
+	random     *rand.Rand
+}
+
+// Option is used for advanced backoff configuration
+type Option func(b *Backoff)
+
+// WithMultiplier sets how fast the wait grows between attempts
+func WithMultiplier(multiplier float64) Option {
+	return func(b *Backoff) {
+		b.multiplier = multiplier
+	}
+}
+
+// WithClock sets clock used by Wait
+func WithClock(clock Clock) Option {
+	return func(b *Backoff) {
+		b.clock = clock
+	}
+}
+
+// WithContext stops the backoff when ctx is done, NextBackOff returns Stop
+// and retry loops waiting on the backoff exit
+func WithContext(ctx context.Context) Option {
+	return func(b *Backoff) {
+		b.ctx = ctx
+	}
+}
+
+// WithRandom sets random source, tests can use it to make jitter predictable
+func WithRandom(random *rand.Rand) Option {
+	return func(b *Backoff) {
+		b.random = random
+	}
+}
+
+// New creates backoff waiting between zero and max, starting from min
+func New(min, max time.Duration, options ...Option) *Backoff {
+	b := &Backoff{
+		min:        min,
+		max:        max,
+		multiplier: 2,
+		clock:      SystemClock{},
+		ctx:        context.Background(),
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// Validate reports ErrInvalidRange when min and max cannot be used by New
+func Validate(min, max time.Duration) error {
+	if min <= 0 || min > max {
+		return ErrInvalidRange
+	}
+	return nil
+}
+
+// NextBackOff returns duration to wait before next attempt, or Stop when context is done
+func (b *Backoff) NextBackOff() time.Duration {
+	if b.ctx.Err() != nil {
+		return Stop
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	ceiling := float64(b.min) * math.Pow(b.multiplier, float64(b.attempt))
+	if ceiling > float64(b.max) || math.IsInf(ceiling, 0) {
+		ceiling = float64(b.max)
+	}
+	b.attempt++
+	return time.Duration(b.random.Float64() * ceiling)
+}
+
+// Reset backoff to initial state after successful attempt
+func (b *Backoff) Reset() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.attempt = 0
+}
+
+// Attempts returns number of failed attempts since last reset
+func (b *Backoff) Attempts() int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.attempt
+}
+
+// Context returns context set by WithContext, the sse client stops reconnecting when it is done
+func (b *Backoff) Context() context.Context {
+	return b.ctx
+}
+
+// Wait blocks for the next backoff duration or until ctx is done
+func (b *Backoff) Wait(ctx context.Context) error {
+	next := b.NextBackOff()
+	if next == Stop {
+		return b.ctx.Err()
+	}
+	return Sleep(ctx, b.clock, next)
+}
+
+// Jitter returns d randomly changed by up to factor of its value in both directions,
+// used to spread periodic work of many instances
+func Jitter(d time.Duration, factor float64) time.Duration {
+	if factor <= 0 {
+		return d
+	}
+	delta := factor * float64(d)
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta) //nolint:gosec
+}
+
+// Sleep waits for d on clock or until ctx is done
+func Sleep(ctx context.Context, clock Clock, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock records requested waits and fires them immediately
+type fakeClock struct {
+	mux   sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestNextBackOffGrowsWithinCeiling(t *testing.T) {
+	b := New(time.Second, time.Second*10, WithRandom(rand.New(rand.NewSource(1))))
+
+	ceilings := []time.Duration{1, 2, 4, 8, 10, 10, 10}
+	for i, ceiling := range ceilings {
+		next := b.NextBackOff()
+		if next < 0 || next > ceiling*time.Second {
+			t.Fatalf("attempt %d: %v is outside of [0, %v]", i, next, ceiling*time.Second)
+		}
+	}
+	if b.Attempts() != len(ceilings) {
+		t.Fatalf("expected %d attempts, got %d", len(ceilings), b.Attempts())
+	}
+
+	b.Reset()
+	if b.Attempts() != 0 {
+		t.Fatalf("expected no attempts after reset, got %d", b.Attempts())
+	}
+	if next := b.NextBackOff(); next > time.Second {
+		t.Fatalf("expected first ceiling after reset, got %v", next)
+	}
+}
+
+func TestNextBackOffStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := New(time.Second, time.Minute, WithContext(ctx))
+	if b.Context() != ctx {
+		t.Fatal("expected context set by option")
+	}
+	if next := b.NextBackOff(); next == Stop {
+		t.Fatal("unexpected stop before cancel")
+	}
+
+	cancel()
+	if next := b.NextBackOff(); next != Stop {
+		t.Fatalf("expected stop after cancel, got %v", next)
+	}
+	if err := b.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
+
+func TestWaitUsesClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b := New(time.Second, time.Second*4, WithClock(clock), WithMultiplier(2))
+
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.waits) != 4 {
+		t.Fatalf("expected 4 waits on clock, got %d", len(clock.waits))
+	}
+	for i, wait := range clock.waits {
+		if wait > time.Second*4 {
+			t.Fatalf("wait %d: %v is over max", i, wait)
+		}
+	}
+}
+
+func TestSleepReturnsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Sleep(ctx, SystemClock{}, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if Jitter(time.Minute, 0) != time.Minute {
+		t.Fatal("expected no jitter for zero factor")
+	}
+	for i := 0; i < 100; i++ {
+		d := Jitter(time.Minute, 0.1)
+		if d < time.Second*54 || d > time.Second*66 {
+			t.Fatalf("%v is outside of 10%% of a minute", d)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		min, max time.Duration
+		valid    bool
+	}{
+		{time.Second, time.Minute, true},
+		{time.Second, time.Second, true},
+		{0, 0, false},
+		{-time.Second, time.Second, false},
+		{time.Minute, time.Second, false},
+	}
+	for _, test := range tests {
+		err := Validate(test.min, test.max)
+		if test.valid != (err == nil) {
+			t.Errorf("Validate(%v, %v) = %v", test.min, test.max, err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("expected ErrInvalidRange, got %v", err)
+		}
+	}
+}
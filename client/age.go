@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"time"
+)
+
+// StalePolicy decides what happens when data gets older than max age
+type StalePolicy int
+
+const (
+	// StaleLog logs an error and keeps serving stale data
+	StaleLog StalePolicy = iota
+	// StaleDefaults evaluates flags to default values while data is stale
+	StaleDefaults
+)
+
+// StaleListener is notified when data gets stale and when it is fresh again
+type StaleListener func(stale bool, age time.Duration)
+
+// Details is evaluation together with information about data it was made from
+type Details struct {
+	Evaluation evaluation.Evaluation
+	// DataAge is time since data was last synchronized
+	DataAge time.Duration
+	// Stale is set when data is older than max age
+	Stale bool
+}
+
+// DataAge returns time since data was last synchronized from any source.
+// Stream events and heartbeats count as syncs, so a silent stream ages
+// like any other source. Before the first sync age is counted from client creation.
+func (c *client) DataAge() time.Duration {
+	last := c.repository.LastSynced()
+	if last.IsZero() {
+		last = c.created
+	}
+	return c.config.clock.Now().Sub(last)
+}
+
+// Stale reports if data is older than max age
+func (c *client) Stale() bool {
+	return c.config.maxAge > 0 && c.stale(c.DataAge())
+}
+
+func (c *client) stale(age time.Duration) bool {
+	return c.config.maxAge > 0 && age > c.config.maxAge
+}
+
+// EvaluateDetails evaluates flag and reports age of data used
+func (c *client) EvaluateDetails(feature string, target evaluation.Target) Details {
+	age := c.DataAge()
+	stale := c.stale(age)
+	return Details{
+		Evaluation: c.evaluate(feature, target, stale),
+		DataAge:    age,
+		Stale:      stale,
+	}
+}
+
+// watchAge reports data age to metrics, logs and notifies listeners
+// when data gets stale or fresh again
+func (c *client) watchAge(ctx context.Context) {
+	interval := 15 * time.Second
+	if c.config.maxAge > 0 && c.config.maxAge/4 < interval {
+		interval = c.config.maxAge / 4
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	wasStale := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		age := c.DataAge()
+		c.config.metrics.DataAge(age)
+		stale := c.stale(age)
+		if stale == wasStale {
+			continue
+		}
+		wasStale = stale
+		if stale {
+			log.Errorf("flags data is stale, last synchronized %v ago, max age is %v", age.Round(time.Second), c.config.maxAge)
+		} else {
+			log.Info("flags data is fresh again")
+		}
+		for _, listener := range c.config.staleListeners {
+			listener(stale, age)
+		}
+	}
+}
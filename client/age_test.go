@@ -0,0 +1,89 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// withClock replaces the clock used by the puller and for data age
+func withClock(clock *manualClock) ConfigOption {
+	return func(config *config) {
+		config.clock = clock
+	}
+}
+
+func TestDataAgeBeforeFirstSync(t *testing.T) {
+	conn := newFakeConnector()
+	conn.err.Store(errors.New("api down"))
+	clock := newManualClock()
+	c := newTestClient(t, conn, withClock(clock))
+
+	if age := c.DataAge(); age != 0 {
+		t.Fatalf("expected zero age at creation, got %v", age)
+	}
+	clock.advance(time.Second * 10)
+	if age := c.DataAge(); age != time.Second*10 {
+		t.Fatalf("expected age counted from creation, got %v", age)
+	}
+}
+
+func TestDataAgeOfSilentStream(t *testing.T) {
+	conn := newFakeConnector()
+	conn.err.Store(errors.New("api down"))
+	clock := newManualClock()
+	c := newTestClient(t, conn, withClock(clock), WithMaxAge(time.Minute, StaleLog))
+
+	eventually(t, func() bool { return conn.stream() != nil }, "stream was not started")
+	stream := conn.stream().(*updater)
+	stream.OnConnect()
+	if c.State() != StateStreaming {
+		t.Fatalf("expected streaming, got %s", c.State())
+	}
+	if age := c.DataAge(); age != 0 {
+		t.Fatalf("expected zero age after connect, got %v", age)
+	}
+
+	clock.advance(time.Minute * 2)
+	if age := c.DataAge(); age != time.Minute*2 {
+		t.Fatalf("expected silent stream to age, got %v", age)
+	}
+	if !c.Stale() {
+		t.Fatal("expected silent stream to be stale")
+	}
+
+	stream.OnHeartbeat()
+	if age := c.DataAge(); age != 0 || c.Stale() {
+		t.Fatalf("expected heartbeat to refresh data, got age %v", age)
+	}
+}
+
+func TestStalePolicy(t *testing.T) {
+	for _, policy := range []StalePolicy{StaleLog, StaleDefaults} {
+		conn := newFakeConnector()
+		clock := newManualClock()
+		c := newTestClient(t, conn, withClock(clock), WithPullInterval(3600), WithMaxAge(time.Minute, policy))
+		c.WaitForInitialization()
+
+		details := c.EvaluateDetails("dark_mode", nil)
+		if details.Stale || details.DataAge != 0 {
+			t.Fatalf("policy %d: expected fresh data after pull, got %+v", policy, details)
+		}
+		if reason := c.reason(details.Stale); reason != ReasonUnknown {
+			t.Fatalf("policy %d: unexpected reason %s of fresh data", policy, reason)
+		}
+
+		clock.advance(time.Minute + time.Second)
+		details = c.EvaluateDetails("dark_mode", nil)
+		if !details.Stale || details.DataAge != time.Minute+time.Second {
+			t.Fatalf("policy %d: expected stale data, got %+v", policy, details)
+		}
+		expected := ReasonStale
+		if policy == StaleDefaults {
+			expected = ReasonDefault
+		}
+		if reason := c.reason(details.Stale); reason != expected {
+			t.Fatalf("policy %d: expected reason %s, got %s", policy, expected, reason)
+		}
+	}
+}
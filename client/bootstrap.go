@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"github.com/simpleflags/golang-server-sdk/repository"
+)
+
+// InitSource tells where data served by the client was loaded from
+type InitSource string
+
+const (
+	// InitNone client has no data loaded yet
+	InitNone InitSource = "none"
+	// InitBootstrapped client serves data given by WithBootstrap or WithBootstrapFile
+	InitBootstrapped InitSource = "bootstrapped"
+	// InitServer client serves data loaded by the connector
+	InitServer InitSource = "server"
+	// InitStorage client serves data persisted in storage by previous runs
+	InitStorage InitSource = "storage"
+)
+
+// bootstrap preloads repository before the first pull and reports if any data was loaded.
+// Repository keeps higher versions, so server data replaces bootstrap data when it is newer.
+func bootstrap(repo repository.Repository, config config) (bool, error) {
+	configurations := config.bootstrapConfigurations
+	variables := config.bootstrapVariables
+
+	if config.bootstrapFS != nil {
+		file, err := config.bootstrapFS.Open(config.bootstrapPath)
+		if err != nil {
+			return false, fmt.Errorf("bootstrap file: %w", err)
+		}
+		defer file.Close()
+		snapshot, err := repository.ReadSnapshot(file)
+		if err != nil {
+			return false, fmt.Errorf("bootstrap file %s: %w", config.bootstrapPath, err)
+		}
+		configurations = append(configurations, snapshot.Configurations...)
+		variables = append(variables, snapshot.Variables...)
+	}
+
+	for i := range configurations {
+		repo.SetConfiguration(&configurations[i])
+	}
+	for i := range variables {
+		repo.SetVariable(&variables[i])
+	}
+
+	loaded := len(configurations) > 0 || len(variables) > 0
+	if loaded {
+		repo.MarkSynced(repository.SyncBootstrap)
+		log.Infof("client bootstrapped with %d flags and %d variables", len(configurations), len(variables))
+	}
+	return loaded, nil
+}
@@ -3,12 +3,18 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/looplab/fsm"
 	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/backoff"
 	"github.com/simpleflags/golang-server-sdk/connector"
 	"github.com/simpleflags/golang-server-sdk/connector/simple"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"github.com/simpleflags/golang-server-sdk/metrics"
 	"github.com/simpleflags/golang-server-sdk/repository"
 	"go.uber.org/atomic"
+	"sync"
+	"time"
 )
 
 // client is the Feature Flag client.
@@ -31,6 +37,14 @@ type client struct {
 	stop       chan struct{}
 	stopped    *atomic.Bool
 	state      *fsm.FSM
+	// bootstrapped is set when data was preloaded before the first pull
+	bootstrapped bool
+	// created is used as data age before the first sync
+	created time.Time
+	// ctx lives until the client is closed
+	ctx          context.Context
+	streamMux    sync.Mutex
+	cancelStream context.CancelFunc
 }
 
 // New creates a new client instance that connects to CF with the default configuration.
@@ -57,9 +71,19 @@ func NewWithConnector(connector connector.Connector, options ...ConfigOption) (*
 		opt(&config)
 	}
 
-	repo := repository.New(config.cache)
+	repoOptions := []repository.Option{
+		repository.WithCallback(config.callback),
+		repository.WithLookupRecorder(config.metrics),
+		repository.WithClock(config.clock.Now),
+	}
 	if config.storage != nil {
-		repo = repository.New(config.cache, repository.WithStorage(config.storage))
+		repoOptions = append(repoOptions, repository.WithStorage(config.storage))
+	}
+	repo := repository.New(config.cache, repoOptions...)
+
+	bootstrapped, err := bootstrap(repo, config)
+	if err != nil {
+		return nil, err
 	}
 
 	evaluator, err := evaluation.NewEvaluator(repo)
@@ -67,81 +91,222 @@ func NewWithConnector(connector connector.Connector, options ...ConfigOption) (*
 		return nil, err
 	}
 
-	p := newPuller(connector, repo, config.pullInterval, config.flags...)
+	if err := backoff.Validate(config.pullBackoffMin, config.pullBackoffMax); err != nil {
+		return nil, fmt.Errorf("pull backoff: %w", err)
+	}
+	retry := backoff.New(config.pullBackoffMin, config.pullBackoffMax, backoff.WithClock(config.clock))
+	p := newPuller(connector, repo, config.pullInterval, config.pullJitter, config.clock, retry, config.flags...)
+	p.setOffline(config.offline)
+	p.metrics = config.metrics
+	p.tracer = tracer(config.tracerProvider)
 
-	state := fsm.NewFSM("disconnected",
-		fsm.Events{
-			{Name: "connect", Src: []string{"disconnected"}, Dst: "connected"},
-			{Name: "disconnect", Src: []string{"connected"}, Dst: "disconnected"},
+	// ctx lives until the client is closed
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fallback := StateOffline
+	if config.enablePuller {
+		fallback = StatePolling
+	}
+
+	state := newState(fallback, fsm.Callbacks{
+		"before_" + eventConnect: func(e *fsm.Event) {
+			// stream connected just before client was set offline
+			if p.isOffline() {
+				e.Cancel()
+			}
+		},
+		"enter_" + string(StateStreaming): func(e *fsm.Event) {
+			p.stop()
+			// catch up with changes made while stream was down
+			go p.pull(ctx)
+		},
+		"enter_" + string(StatePolling): func(e *fsm.Event) {
+			p.start(ctx)
+		},
+		"enter_" + string(StateOffline): func(e *fsm.Event) {
+			p.stop()
 		},
-		fsm.Callbacks{
-			"enter_connected": func(e *fsm.Event) {
-				p.stop()
-			},
-			"enter_disconnected": func(e *fsm.Event) {
-				//p.start()
-			},
+		"enter_" + string(StateClosed): func(e *fsm.Event) {
+			p.close()
 		},
-	)
-	u := newUpdater(connector, repo, state)
+		"enter_state": func(e *fsm.Event) {
+			log.Infof("client state changed from %s to %s", e.Src, e.Dst)
+			for _, listener := range config.stateListeners {
+				listener(State(e.Src), State(e.Dst))
+			}
+		},
+	})
+	u := newUpdater(connector, repo, state, p)
+	u.metrics = config.metrics
 
 	client := &client{
 		config:     config,
 		repository: repo,
 		connector:  connector,
 		evaluator:  evaluator,
-		puller:     &p,
+		puller:     p,
 		updater:    &u,
 		stop:       make(chan struct{}),
 		stopped:    atomic.NewBool(false),
 		state:      state,
+
+		bootstrapped: bootstrapped,
+		created:      config.clock.Now(),
+		ctx:          ctx,
 	}
 
-	client.start()
+	client.start(cancel)
 
 	return client, nil
 }
 
-func (c *client) WaitForInitialization() {
-	for {
-		if c.puller.initialized() {
-			break
-		}
+// Initialized reports if client was bootstrapped, is offline or the first pull is done
+func (c *client) Initialized() bool {
+	return c.bootstrapped || c.puller.isOffline() || c.puller.initialized()
+}
+
+// WaitForInitialization blocks until the first pull is done and returns source
+// of the data. Bootstrapped and offline clients are ready immediately.
+func (c *client) WaitForInitialization() InitSource {
+	if !c.bootstrapped && !c.puller.isOffline() {
+		<-c.puller.ready
 	}
+	return c.InitSource()
 }
 
-func (c *client) start() {
-	ctx, cancel := context.WithCancel(context.Background())
+// InitSource returns where data served by the client was loaded from
+func (c *client) InitSource() InitSource {
+	switch {
+	case c.puller.synced():
+		return InitServer
+	case c.bootstrapped:
+		return InitBootstrapped
+	case c.config.storage != nil:
+		return InitStorage
+	}
+	return InitNone
+}
+
+func (c *client) start(cancel context.CancelFunc) {
 	go func() {
 		<-c.stop
 		cancel()
 	}()
 
-	if c.config.enablePuller {
-		c.puller.start(ctx)
-	} else {
+	c.updater.start(c.ctx)
+	if _, noop := c.config.metrics.(metrics.Noop); c.config.maxAge > 0 || !noop {
+		go c.watchAge(c.ctx)
+	}
+	if c.puller.isOffline() {
+		fire(c.state, eventOffline)
+		return
+	}
+
+	if !c.config.enablePuller {
 		// good for lambda and short living environments
-		c.puller.pull(ctx)
+		c.puller.pull(c.ctx)
 	}
+	fire(c.state, eventStart)
+	c.startStream()
+}
 
-	if c.config.enableStream {
-		c.updater.start(ctx)
+// SetOffline stops or resumes all connector calls. Offline client serves
+// data it already has, bootstrap data and storage.
+func (c *client) SetOffline(offline bool) {
+	if c.stopped.Load() || !c.puller.setOffline(offline) {
+		return
+	}
+
+	if offline {
+		c.stopStream()
+		fire(c.state, eventOffline)
+		return
+	}
+
+	if !c.config.enablePuller {
+		go c.puller.pull(c.ctx)
+	}
+	fire(c.state, eventOnline)
+	c.startStream()
+}
+
+// Offline reports if client was set offline
+func (c *client) Offline() bool {
+	return c.puller.isOffline()
+}
+
+func (c *client) startStream() {
+	if !c.config.enableStream {
+		return
+	}
+	c.streamMux.Lock()
+	defer c.streamMux.Unlock()
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.cancelStream = cancel
+	c.updater.stream(ctx)
+}
+
+func (c *client) stopStream() {
+	c.streamMux.Lock()
+	defer c.streamMux.Unlock()
+	if c.cancelStream != nil {
+		c.cancelStream()
+		c.cancelStream = nil
 	}
 }
 
+// State returns current state of data synchronization
+func (c *client) State() State {
+	return State(c.state.Current())
+}
+
 func (c *client) Evaluate(feature string, target evaluation.Target) evaluation.Evaluation {
-	eval := c.evaluator.Evaluate(feature, target)
-	//c.analyticsService.PushToQueue(feature, target, variation)
+	return c.evaluate(feature, target, c.Stale())
+}
+
+// evaluate serves default values for stale data when policy asks for it
+func (c *client) evaluate(feature string, target evaluation.Target, stale bool) evaluation.Evaluation {
+	return c.evaluateWith(c.evaluator, feature, target, stale)
+}
+
+func (c *client) evaluateWith(evaluator *evaluation.Evaluator, feature string, target evaluation.Target, stale bool) evaluation.Evaluation {
+	if stale && c.config.stalePolicy == StaleDefaults {
+		return evaluation.Evaluation{}
+	}
+	_, noop := c.config.metrics.(metrics.Noop)
+	var started time.Time
+	if !noop {
+		started = time.Now()
+	}
+	eval := evaluator.Evaluate(feature, target)
+	if !noop {
+		c.config.metrics.Evaluation(feature, variant(eval), time.Since(started))
+	}
+	// targets leave the process only redacted
+	log.Debugf("flag %s evaluated for target %v", feature, redactedTarget{target: target, private: c.config.privateAttributes})
+	//c.analyticsService.PushToQueue(feature, c.Redact(target), variation)
 	return eval
 }
 
+// EvaluateAll evaluates every flag held by the client for the target
+func (c *client) EvaluateAll(target evaluation.Target) map[string]evaluation.Evaluation {
+	configurations := c.repository.Configurations()
+	evaluations := make(map[string]evaluation.Evaluation, len(configurations))
+	for _, config := range configurations {
+		evaluations[config.Identifier] = c.Evaluate(config.Identifier, target)
+	}
+	return evaluations
+}
+
 // Close shuts down the Feature Flag client. After calling this, the client
 // should no longer be used
 func (c *client) Close() error {
 	if c.stopped.Load() {
 		return errors.New("client already closed")
 	}
+	fire(c.state, eventClose)
 	close(c.stop)
+	c.updater.close()
 
 	c.stopped.Store(true)
 	return nil
@@ -1,18 +1,44 @@
 package client
 
 import (
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/backoff"
+	"github.com/simpleflags/golang-server-sdk/metrics"
 	"github.com/simpleflags/golang-server-sdk/repository"
+	"go.opentelemetry.io/otel/trace"
+	"io/fs"
+	"time"
 )
 
 type config struct {
 	pullInterval    uint // in seconds
+	pullJitter      float64
+	pullBackoffMin  time.Duration
+	pullBackoffMax  time.Duration
+	clock           backoff.Clock
 	pushInterval    uint // in seconds
 	cache           repository.Cache
 	storage         repository.Storage
+	callback        repository.Callback
 	enablePuller    bool
 	enableStream    bool
 	enableAnalytics bool
+	offline         bool
+	metrics         metrics.Recorder
+	tracerProvider  trace.TracerProvider
 	flags           []string
+	stateListeners  []StateListener
+	// data older than maxAge is stale, zero disables the check
+	maxAge         time.Duration
+	stalePolicy    StalePolicy
+	staleListeners []StaleListener
+	// privateAttributes are redacted from targets leaving the process
+	privateAttributes []string
+	// bootstrap data preloaded before the first pull
+	bootstrapConfigurations evaluation.Configurations
+	bootstrapVariables      []evaluation.Variable
+	bootstrapFS             fs.FS
+	bootstrapPath           string
 }
 
 func newDefaultConfig() (config, error) {
@@ -24,11 +50,16 @@ func newDefaultConfig() (config, error) {
 
 	return config{
 		pullInterval:    60,
+		pullJitter:      0.1,
+		pullBackoffMin:  time.Second,
+		pullBackoffMax:  time.Minute * 5,
+		clock:           backoff.SystemClock{},
 		pushInterval:    60,
 		cache:           defaultCache,
 		enablePuller:    true,
 		enableStream:    true,
 		enableAnalytics: true,
+		metrics:         metrics.Noop{},
 		flags:           []string{},
 	}, nil
 }
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type targetKey struct{}
+
+// WithTarget returns context carrying the target used by EvaluateFromContext
+func WithTarget(ctx context.Context, target evaluation.Target) context.Context {
+	return context.WithValue(ctx, targetKey{}, target)
+}
+
+// TargetFromContext returns target stored by WithTarget
+func TargetFromContext(ctx context.Context) (evaluation.Target, bool) {
+	target, ok := ctx.Value(targetKey{}).(evaluation.Target)
+	return target, ok
+}
+
+// EvaluateCtx evaluates flag and records feature_flag event on the span in ctx,
+// following OpenTelemetry semantic conventions for feature flags. When ctx is done
+// before data is read from storage default value is served.
+func (c *client) EvaluateCtx(ctx context.Context, feature string, target evaluation.Target) evaluation.Evaluation {
+	stale := c.Stale()
+	eval, reason := c.evaluateCtx(ctx, feature, target, stale)
+
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		attributes := []attribute.KeyValue{
+			attribute.String("feature_flag.key", feature),
+			attribute.String("feature_flag.provider_name", providerName),
+			attribute.String("feature_flag.evaluation.reason", reason),
+		}
+		if v := variant(eval); v != "" {
+			attributes = append(attributes, attribute.String("feature_flag.variant", v))
+		}
+		span.AddEvent("feature_flag", trace.WithAttributes(attributes...))
+	}
+	return eval
+}
+
+// EvaluateFromContext is EvaluateCtx for the target stored in ctx by WithTarget,
+// empty target is used when there is none
+func (c *client) EvaluateFromContext(ctx context.Context, feature string) evaluation.Evaluation {
+	target, ok := TargetFromContext(ctx)
+	if !ok {
+		target = evaluation.Target{}
+	}
+	return c.EvaluateCtx(ctx, feature, target)
+}
+
+// evaluateCtx evaluates within ctx, only lookups in storage can block. They are
+// bounded by ctx when storage implements repository.ContextStorage, evaluation
+// runs in the caller's goroutine so nothing is left running after the deadline.
+func (c *client) evaluateCtx(ctx context.Context, feature string, target evaluation.Target, stale bool) (evaluation.Evaluation, string) {
+	if ctx.Err() != nil {
+		return evaluation.Evaluation{}, ReasonError
+	}
+	if _, ok := ctx.Deadline(); !ok || c.config.storage == nil {
+		return c.evaluate(feature, target, stale), c.reason(stale)
+	}
+
+	evaluator, err := evaluation.NewEvaluator(c.repository.WithContext(ctx))
+	if err != nil {
+		return evaluation.Evaluation{}, ReasonError
+	}
+	eval := c.evaluateWith(evaluator, feature, target, stale)
+	if ctx.Err() != nil {
+		// lookups were cut off, the evaluation may be based on missing data
+		return evaluation.Evaluation{}, ReasonError
+	}
+	return eval, c.reason(stale)
+}
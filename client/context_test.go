@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	"go.uber.org/atomic"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// slowStorage has no data, lookups wait until ctx is done or storage is released
+type slowStorage struct {
+	pending *atomic.Int64
+	release chan struct{}
+}
+
+func (s slowStorage) Get(key string, output interface{}) error {
+	s.pending.Inc()
+	defer s.pending.Dec()
+	<-s.release
+	return os.ErrNotExist
+}
+
+func (s slowStorage) GetContext(ctx context.Context, key string, output interface{}) error {
+	s.pending.Inc()
+	defer s.pending.Dec()
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s slowStorage) Set(key string, value interface{}) error { return nil }
+func (s slowStorage) Remove(key string) error                 { return nil }
+func (s slowStorage) List() []interface{}                     { return nil }
+
+func TestEvaluateCtxLeavesNothingRunningAfterDeadline(t *testing.T) {
+	storage := slowStorage{pending: atomic.NewInt64(0), release: make(chan struct{})}
+	t.Cleanup(func() { close(storage.release) })
+	c := newTestClient(t, newFakeConnector(), WithStreamEnabled(false), WithStorage(storage))
+	c.WaitForInitialization()
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*5)
+		eval, reason := c.evaluateCtx(ctx, "dark_mode", evaluation.Target{}, false)
+		cancel()
+		if reason != ReasonError {
+			t.Fatalf("expected error reason after deadline, got %s with %+v", reason, eval)
+		}
+	}
+
+	if pending := storage.pending.Load(); pending != 0 {
+		t.Fatalf("expected no lookups left running, got %d", pending)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Fatalf("expected no goroutines left running, had %d, got %d", before, after)
+	}
+}
+
+func TestEvaluateFromContextReadsTarget(t *testing.T) {
+	c := newTestClient(t, newFakeConnector(), WithStreamEnabled(false))
+	target := evaluation.Target{"identifier": "john"}
+
+	ctx := WithTarget(context.Background(), target)
+	if got, ok := TargetFromContext(ctx); !ok || got["identifier"] != "john" {
+		t.Fatalf("expected target in context, got %v", got)
+	}
+	if _, ok := TargetFromContext(context.Background()); ok {
+		t.Fatal("expected no target in empty context")
+	}
+	_ = c.EvaluateFromContext(ctx, "dark_mode")
+	_ = c.EvaluateFromContext(context.Background(), "dark_mode")
+}
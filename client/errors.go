@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrSdkCantBeEmpty       = errors.New("SDK key cannot be empty")
 	ErrConnectorCannotBeNil = errors.New("connector cannot be nil")
+	ErrInvalidTarget        = errors.New("invalid target")
 )
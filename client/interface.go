@@ -1,11 +1,30 @@
 package client
 
 import (
+	"context"
 	"github.com/simpleflags/evaluation"
+	"net/http"
+	"time"
 )
 
 type Client interface {
-	WaitForInitialization()
+	WaitForInitialization() InitSource
+	Initialized() bool
+	InitSource() InitSource
 	Evaluate(feature string, target evaluation.Target) evaluation.Evaluation
+	EvaluateCtx(ctx context.Context, feature string, target evaluation.Target) evaluation.Evaluation
+	EvaluateFromContext(ctx context.Context, feature string) evaluation.Evaluation
+	EvaluateAll(target evaluation.Target) map[string]evaluation.Evaluation
+	EvaluateDetails(feature string, target evaluation.Target) Details
+	SetOffline(offline bool)
+	State() State
+	Status() Status
+	DataAge() time.Duration
+	Stale() bool
+	StatusHandler() http.Handler
+	Redact(target evaluation.Target) evaluation.Target
+	CheckTarget(target evaluation.Target) error
 	Close() error
 }
+
+var _ Client = &client{}
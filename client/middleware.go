@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	"google.golang.org/grpc"
+	"net/http"
+)
+
+// HTTPTargetExtractor builds target from the incoming request
+type HTTPTargetExtractor func(r *http.Request) evaluation.Target
+
+// GRPCTargetExtractor builds target from the incoming call, metadata is in ctx
+type GRPCTargetExtractor func(ctx context.Context, fullMethod string) evaluation.Target
+
+// Middleware stores target built by extractor in the request context,
+// so handlers can call EvaluateFromContext without passing the target around
+func Middleware(extractor HTTPTargetExtractor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithTarget(r.Context(), extractor(r))))
+		})
+	}
+}
+
+// UnaryServerInterceptor stores target built by extractor in the call context
+func UnaryServerInterceptor(extractor GRPCTargetExtractor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(WithTarget(ctx, extractor(ctx, info.FullMethod)), req)
+	}
+}
+
+// StreamServerInterceptor stores target built by extractor in the stream context
+func StreamServerInterceptor(extractor GRPCTargetExtractor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := WithTarget(ss.Context(), extractor(ss.Context(), info.FullMethod))
+		return handler(srv, &targetStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// targetStream overrides context of the wrapped stream
+type targetStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *targetStream) Context() context.Context {
+	return s.ctx
+}
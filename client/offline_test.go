@@ -0,0 +1,47 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOfflineClientNeverCallsConnector(t *testing.T) {
+	conn := newFakeConnector()
+	clock := newManualClock()
+	c := newTestClient(t, conn, withClock(clock), WithOffline(true), WithPullInterval(60))
+
+	c.WaitForInitialization()
+	for i := 0; i < 3; i++ {
+		clock.advance(time.Minute * 2)
+		c.Evaluate("dark_mode", nil)
+		c.EvaluateAll(nil)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if pulls, streams := conn.pulls.Load(), conn.streams.Load(); pulls != 0 || streams != 0 {
+		t.Fatalf("offline client made %d pulls and %d stream starts", pulls, streams)
+	}
+}
+
+func TestSetOfflineStopsConnectorCalls(t *testing.T) {
+	conn := newFakeConnector()
+	clock := newManualClock()
+	c := newTestClient(t, conn, withClock(clock), WithPullInterval(60))
+	c.WaitForInitialization()
+	eventually(t, func() bool { return conn.streams.Load() == 1 }, "stream was not started")
+
+	c.SetOffline(true)
+	pulls, streams := conn.pulls.Load(), conn.streams.Load()
+	for i := 0; i < 3; i++ {
+		clock.advance(time.Minute * 2)
+		c.Evaluate("dark_mode", nil)
+		c.EvaluateAll(nil)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if conn.pulls.Load() != pulls || conn.streams.Load() != streams {
+		t.Fatalf("offline client called connector, %d pulls and %d stream starts after going offline",
+			conn.pulls.Load()-pulls, conn.streams.Load()-streams)
+	}
+
+	c.SetOffline(false)
+	eventually(t, func() bool { return conn.streams.Load() == streams+1 }, "stream was not restarted online")
+}
@@ -1,7 +1,12 @@
 package client
 
 import (
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/metrics"
 	"github.com/simpleflags/golang-server-sdk/repository"
+	"go.opentelemetry.io/otel/trace"
+	"io/fs"
+	"time"
 )
 
 // ConfigOption is used as return value for advanced client configuration
@@ -15,6 +20,23 @@ func WithPullInterval(interval uint) ConfigOption {
 	}
 }
 
+// WithPullJitter spreads pulls randomly by up to factor of the pull interval,
+// so many instances don't hit the api at the same time
+func WithPullJitter(factor float64) ConfigOption {
+	return func(config *config) {
+		config.pullJitter = factor
+	}
+}
+
+// WithPullBackoff sets exponential backoff used to retry failed pulls,
+// min must be positive and not greater than max
+func WithPullBackoff(min, max time.Duration) ConfigOption {
+	return func(config *config) {
+		config.pullBackoffMin = min
+		config.pullBackoffMax = max
+	}
+}
+
 // WithCache set custom cache or predefined one from cache package
 func WithCache(cache repository.Cache) ConfigOption {
 	return func(config *config) {
@@ -29,6 +51,13 @@ func WithStorage(storage repository.Storage) ConfigOption {
 	}
 }
 
+// WithCallback set callback notified when flags and variables are stored or deleted
+func WithCallback(callback repository.Callback) ConfigOption {
+	return func(config *config) {
+		config.callback = callback
+	}
+}
+
 // WithPullerEnabled set puller on or off
 func WithPullerEnabled(val bool) ConfigOption {
 	return func(config *config) {
@@ -43,9 +72,81 @@ func WithStreamEnabled(val bool) ConfigOption {
 	}
 }
 
+// WithOffline serves flags only from bootstrap data and storage,
+// the connector is never called until client.SetOffline(false)
+func WithOffline(val bool) ConfigOption {
+	return func(config *config) {
+		config.offline = val
+	}
+}
+
 // WithPrefetchFlags set of flags to be prefetched
 func WithPrefetchFlags(identifiers ...string) ConfigOption {
 	return func(config *config) {
 		config.flags = identifiers
 	}
 }
+
+// WithStateListener adds listener notified on every client state change
+func WithStateListener(listener StateListener) ConfigOption {
+	return func(config *config) {
+		config.stateListeners = append(config.stateListeners, listener)
+	}
+}
+
+// WithBootstrap preloads flags and variables, client can evaluate them before
+// or without any fetch from the connector. Newer versions from the server replace them.
+func WithBootstrap(configurations evaluation.Configurations, variables []evaluation.Variable) ConfigOption {
+	return func(config *config) {
+		config.bootstrapConfigurations = append(config.bootstrapConfigurations, configurations...)
+		config.bootstrapVariables = append(config.bootstrapVariables, variables...)
+	}
+}
+
+// WithBootstrapFile preloads snapshot bundle written by sfctl export or repository.WriteSnapshot,
+// for example embedded into the binary with embed.FS
+func WithBootstrapFile(fsys fs.FS, path string) ConfigOption {
+	return func(config *config) {
+		config.bootstrapFS = fsys
+		config.bootstrapPath = path
+	}
+}
+
+// WithMaxAge sets how old data can get, pulls, stream events and stream heartbeats
+// refresh it, policy decides what happens with stale data
+func WithMaxAge(maxAge time.Duration, policy StalePolicy) ConfigOption {
+	return func(config *config) {
+		config.maxAge = maxAge
+		config.stalePolicy = policy
+	}
+}
+
+// WithStaleListener is notified when data gets older than max age and when it is fresh again
+func WithStaleListener(listener StaleListener) ConfigOption {
+	return func(config *config) {
+		config.staleListeners = append(config.staleListeners, listener)
+	}
+}
+
+// WithMetrics reports SDK metrics to recorder, see metrics/prometheus and metrics/otel
+func WithMetrics(recorder metrics.Recorder) ConfigOption {
+	return func(config *config) {
+		config.metrics = recorder
+	}
+}
+
+// WithTracerProvider sets provider of spans for pulls, default is the global provider
+// which does nothing until the application sets one
+func WithTracerProvider(provider trace.TracerProvider) ConfigOption {
+	return func(config *config) {
+		config.tracerProvider = provider
+	}
+}
+
+// WithPrivateAttributes marks target attributes which are used in evaluation
+// but are redacted from everything leaving the process
+func WithPrivateAttributes(names ...string) ConfigOption {
+	return func(config *config) {
+		config.privateAttributes = append(config.privateAttributes, names...)
+	}
+}
@@ -2,11 +2,17 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/backoff"
 	"github.com/simpleflags/golang-server-sdk/connector"
 	"github.com/simpleflags/golang-server-sdk/log"
+	"github.com/simpleflags/golang-server-sdk/metrics"
 	"github.com/simpleflags/golang-server-sdk/repository"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
+	"sync"
 	"time"
 )
 
@@ -14,25 +20,51 @@ type puller struct {
 	interval    uint
 	connector   connector.Connector
 	repository  repository.Repository
-	stopped     chan struct{}
 	init        *atomic.Bool
 	identifiers []string
+	jitter      float64
+	backoff     *backoff.Backoff
+	clock       backoff.Clock
+	mux         sync.Mutex
+	cancel      context.CancelFunc
+	// pullMux makes sure only one pull runs at a time
+	pullMux sync.Mutex
+	// ready is closed when the first pull is done
+	ready chan struct{}
+	// loaded is set once a pull succeeded
+	loaded *atomic.Bool
+	// offline skips all pulls
+	offline *atomic.Bool
+	// lastPull is unix nano time when the last pull finished
+	lastPull *atomic.Int64
+	lastErr  *atomic.Error
+	metrics  metrics.Recorder
+	tracer   trace.Tracer
 }
 
-func newPuller(connector connector.Connector, repository repository.Repository, interval uint,
-	identifiers ...string) puller {
+func newPuller(connector connector.Connector, repository repository.Repository, interval uint, jitter float64,
+	clock backoff.Clock, retry *backoff.Backoff, identifiers ...string) *puller {
 
-	return puller{
+	return &puller{
 		interval:    interval,
 		connector:   connector,
 		repository:  repository,
-		stopped:     make(chan struct{}),
 		init:        atomic.NewBool(false),
+		ready:       make(chan struct{}),
+		loaded:      atomic.NewBool(false),
+		offline:     atomic.NewBool(false),
+		lastPull:    atomic.NewInt64(0),
+		lastErr:     atomic.NewError(nil),
+		metrics:     metrics.Noop{},
+		tracer:      trace.NewNoopTracerProvider().Tracer(instrumentationName),
 		identifiers: identifiers,
+		jitter:      jitter,
+		backoff:     retry,
+		clock:       clock,
 	}
 }
 
-func (p puller) flags(ctx context.Context) (evaluation.Configurations, error) {
+func (p *puller) flags(ctx context.Context) (evaluation.Configurations, error) {
 	configurations, err := p.connector.Configurations(ctx, p.identifiers...)
 	if err != nil {
 		return evaluation.Configurations{}, err
@@ -44,28 +76,53 @@ func (p puller) flags(ctx context.Context) (evaluation.Configurations, error) {
 	return configurations, nil
 }
 
-func (p puller) variables(ctx context.Context, identifiers ...string) {
+func (p *puller) variables(ctx context.Context, identifiers ...string) error {
 	variables, err := p.connector.Variables(ctx, identifiers...)
 	if err != nil {
-		log.Errorf("error loading variables from server %v", err)
+		return err
 	}
 
 	for _, variable := range variables {
 		p.repository.SetVariable(&variable)
 	}
+	return nil
 }
 
-func (p puller) initialized() bool {
+func (p *puller) initialized() bool {
 	return p.init.Load()
 }
 
-func (p puller) pull(ctx context.Context) {
+// synced reports if flags and variables were loaded from the connector at least once
+func (p *puller) synced() bool {
+	return p.loaded.Load()
+}
+
+// setOffline switches pulls off or on and reports if the mode changed
+func (p *puller) setOffline(offline bool) bool {
+	return p.offline.Swap(offline) != offline
+}
+
+func (p *puller) isOffline() bool {
+	return p.offline.Load()
+}
+
+// pull loads flags and their variables, concurrent calls wait for each other.
+// Offline puller never calls the connector.
+func (p *puller) pull(ctx context.Context) error {
+	p.pullMux.Lock()
+	defer p.pullMux.Unlock()
+	if p.offline.Load() {
+		return nil
+	}
 	log.Info("puller iteration")
+	started := p.clock.Now()
+	ctx, span := p.tracer.Start(ctx, "simpleflags.pull")
+	defer span.End()
 
 	// first load flags from server
-	configs, err := p.flags(ctx)
-	if err != nil {
-		log.Errorf("error loading flags from server %v", err)
+	configs, pullErr := p.flags(ctx)
+	if pullErr != nil {
+		log.Errorf("error loading flags from server %v", pullErr)
 	}
 
 	// extract all variables from fetched flags
@@ -74,35 +131,72 @@ func (p puller) pull(ctx context.Context) {
 		for _, rule := range cnf.Rules {
 			vars, err := evaluation.Variables(rule.Expression)
 			if err != nil {
-				return
+				// the other flags are still usable, the pull is reported as failed
+				log.Errorf("invalid rule of flag %s: %v", cnf.Identifier, err)
+				if pullErr == nil {
+					pullErr = fmt.Errorf("flag %s: %w", cnf.Identifier, err)
+				}
+				continue
 			}
 			variables = append(variables, vars...)
 		}
 	}
 	// load variables
-	p.variables(ctx, variables...)
+	if err := p.variables(ctx, variables...); err != nil {
+		log.Errorf("error loading variables from server %v", err)
+		if pullErr == nil {
+			pullErr = err
+		}
+	}
 
-	if !p.init.Load() {
-		p.init.Store(true)
+	p.metrics.Poll(p.clock.Now().Sub(started), pullErr)
+	if pullErr != nil {
+		span.RecordError(pullErr)
+		span.SetStatus(codes.Error, pullErr.Error())
+	}
+	p.lastPull.Store(p.clock.Now().UnixNano())
+	p.lastErr.Store(pullErr)
+	if pullErr == nil {
+		p.loaded.Store(true)
+		p.repository.MarkSynced(repository.SyncPull)
+	}
+	if p.init.CAS(false, true) {
+		close(p.ready)
 		log.Info("puller initialized")
 	}
+	return pullErr
+}
+
+// last returns time and error of the last pull, time is zero before the first pull
+func (p *puller) last() (time.Time, error) {
+	nanos := p.lastPull.Load()
+	if nanos == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, nanos), p.lastErr.Load()
 }
 
-func (p puller) start(ctx context.Context) {
+func (p *puller) start(ctx context.Context) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.cancel != nil {
+		return
+	}
 	log.Info("Starting puller")
 
-	pullingTicker := time.NewTicker(time.Second * time.Duration(p.interval))
+	ctx, p.cancel = context.WithCancel(ctx)
 	go func() {
-		p.pull(ctx)
 		for {
-			select {
-			case <-p.stopped:
-				return
-			case <-ctx.Done():
-				pullingTicker.Stop()
+			// pulls never overlap because next one is scheduled after previous one is done
+			wait := backoff.Jitter(time.Second*time.Duration(p.interval), p.jitter)
+			if err := p.pull(ctx); err != nil {
+				wait = p.backoff.NextBackOff()
+				log.Infof("retrying pull in %v", wait)
+			} else {
+				p.backoff.Reset()
+			}
+			if backoff.Sleep(ctx, p.clock, wait) != nil {
 				return
-			case <-pullingTicker.C:
-				go p.pull(ctx)
 			}
 		}
 	}()
@@ -110,15 +204,27 @@ func (p puller) start(ctx context.Context) {
 	log.Info("Poller started")
 }
 
-func (p puller) stop() {
+// stop the puller, it is safe to call when puller is not running
+func (p *puller) stop() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.cancel == nil {
+		return
+	}
 	log.Info("Stopping puller")
-	p.stopped <- struct{}{}
+	p.cancel()
+	p.cancel = nil
 	log.Info("Poller stopped")
 }
 
-func (p puller) close() {
+func (p *puller) running() bool {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.cancel != nil
+}
+
+func (p *puller) close() {
 	log.Info("Closing puller")
 	p.stop()
-	close(p.stopped)
 	log.Info("Poller closed")
 }
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/backoff"
+	"github.com/simpleflags/golang-server-sdk/repository"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock blocks waits until the test advances time
+type manualClock struct {
+	mux     sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+	waits   chan time.Duration
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newManualClock() *manualClock {
+	return &manualClock{
+		now:   time.Unix(1000, 0),
+		waits: make(chan time.Duration, 100),
+	}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mux.Lock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, manualWaiter{at: c.now.Add(d), ch: ch})
+	c.mux.Unlock()
+	c.waits <- d
+	return ch
+}
+
+// advance moves time forward and fires all waits which are due
+func (c *manualClock) advance(d time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// next returns duration of the next wait requested by the puller
+func (c *manualClock) next(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("puller did not wait on the clock")
+		return 0
+	}
+}
+
+func newTestPuller(t *testing.T, conn *fakeConnector, clock *manualClock, jitter float64) *puller {
+	t.Helper()
+	cache, err := repository.NewLruCache(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retry := backoff.New(time.Second, time.Second*8, backoff.WithClock(clock),
+		backoff.WithRandom(rand.New(rand.NewSource(1))))
+	return newPuller(conn, repository.New(cache), 60, jitter, clock, retry)
+}
+
+func TestPullerBacksOffOnErrors(t *testing.T) {
+	conn := newFakeConnector()
+	conn.err.Store(errors.New("api down"))
+	clock := newManualClock()
+	p := newTestPuller(t, conn, clock, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.start(ctx)
+	defer p.stop()
+
+	ceilings := []time.Duration{1, 2, 4, 8, 8}
+	for i, ceiling := range ceilings {
+		wait := clock.next(t)
+		if wait < 0 || wait > ceiling*time.Second {
+			t.Fatalf("attempt %d: wait %v is outside of [0, %v]", i, wait, ceiling*time.Second)
+		}
+		if pulls := conn.pulls.Load(); pulls != int64(i+1) {
+			t.Fatalf("attempt %d: expected %d pulls, got %d", i, i+1, pulls)
+		}
+		if i == len(ceilings)-1 {
+			conn.err.Store(nil)
+		}
+		clock.advance(wait)
+	}
+
+	// recovery resets backoff and returns to the interval
+	if wait := clock.next(t); wait != time.Minute {
+		t.Fatalf("expected interval after recovery, got %v", wait)
+	}
+	if p.backoff.Attempts() != 0 {
+		t.Fatalf("expected backoff reset after recovery, got %d attempts", p.backoff.Attempts())
+	}
+}
+
+func TestPullerIntervalJitter(t *testing.T) {
+	conn := newFakeConnector()
+	clock := newManualClock()
+	p := newTestPuller(t, conn, clock, 0.1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.start(ctx)
+	defer p.stop()
+
+	for i := 0; i < 10; i++ {
+		wait := clock.next(t)
+		if wait < time.Second*54 || wait > time.Second*66 {
+			t.Fatalf("pull %d: wait %v is outside of jitter", i, wait)
+		}
+		clock.advance(wait)
+	}
+	if pulls := conn.pulls.Load(); pulls != 10 {
+		t.Fatalf("expected 10 pulls, got %d", pulls)
+	}
+}
+
+func TestPullerRecordsClockTime(t *testing.T) {
+	conn := newFakeConnector()
+	clock := newManualClock()
+	p := newTestPuller(t, conn, clock, 0)
+
+	if at, _ := p.last(); !at.IsZero() {
+		t.Fatalf("expected no pull yet, got %v", at)
+	}
+	if err := p.pull(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if at, err := p.last(); !at.Equal(clock.Now()) || err != nil {
+		t.Fatalf("expected pull at %v, got %v %v", clock.Now(), at, err)
+	}
+}
+
+func TestPullerSkipsInvalidExpression(t *testing.T) {
+	conn := newFakeConnector()
+	conn.configs = evaluation.Configurations{
+		{Identifier: "broken", Rules: []evaluation.Rule{{Expression: `country == "CZ`}}},
+		{Identifier: "valid", Rules: []evaluation.Rule{{Expression: `plan == "pro"`}}},
+	}
+	clock := newManualClock()
+	p := newTestPuller(t, conn, clock, 0)
+
+	if err := p.pull(context.Background()); err == nil {
+		t.Fatal("expected pull error for invalid expression")
+	}
+	select {
+	case <-p.ready:
+	default:
+		t.Fatal("expected puller to be initialized after pull with invalid expression")
+	}
+	if at, err := p.last(); !at.Equal(clock.Now()) || err == nil {
+		t.Fatalf("expected failed pull at %v, got %v %v", clock.Now(), at, err)
+	}
+	if p.synced() {
+		t.Fatal("expected pull with invalid expression not to count as synced")
+	}
+	if len(conn.requested) != 1 || conn.requested[0] != "plan" {
+		t.Fatalf("expected variables of valid flag to be loaded, got %v", conn.requested)
+	}
+	if _, err := p.repository.GetConfiguration("valid"); err != nil {
+		t.Fatalf("expected valid flag to be stored: %v", err)
+	}
+}
+
+func TestInvalidPullBackoff(t *testing.T) {
+	for _, option := range []ConfigOption{
+		WithPullBackoff(0, 0),
+		WithPullBackoff(time.Minute, time.Second),
+	} {
+		if _, err := NewWithConnector(newFakeConnector(), option); !errors.Is(err, backoff.ErrInvalidRange) {
+			t.Fatalf("expected ErrInvalidRange, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"sort"
+)
+
+// Redact returns copy of target which is safe to leave the process, for analytics
+// events, logs and diagnostics. Values of private attributes set by WithPrivateAttributes
+// or TargetBuilder.Private are removed and their names are listed under PrivateAttributesKey.
+// Identifier and name are removed too when listed. Evaluation always uses the full target.
+func (c *client) Redact(target evaluation.Target) evaluation.Target {
+	return redact(target, c.config.privateAttributes)
+}
+
+// redactedTarget formats target without private values for logs,
+// redaction runs only when the log message is actually written
+type redactedTarget struct {
+	target  evaluation.Target
+	private []string
+}
+
+func (r redactedTarget) String() string {
+	return fmt.Sprint(map[string]interface{}(redact(r.target, r.private)))
+}
+
+func redact(target evaluation.Target, global []string) evaluation.Target {
+	private := make(map[string]bool, len(global))
+	for _, name := range global {
+		private[name] = true
+	}
+	for _, name := range targetPrivateAttributes(target) {
+		private[name] = true
+	}
+
+	redacted := make(evaluation.Target, len(target))
+	removed := make([]string, 0)
+	for key, value := range target {
+		switch {
+		case key == PrivateAttributesKey:
+		case private[key]:
+			removed = append(removed, key)
+		default:
+			redacted[key] = value
+		}
+	}
+	if len(removed) > 0 {
+		sort.Strings(removed)
+		redacted[PrivateAttributesKey] = removed
+	}
+	return redacted
+}
+
+// targetPrivateAttributes returns names marked private on the target itself,
+// targets decoded from JSON hold them as []interface{}
+func targetPrivateAttributes(target evaluation.Target) []string {
+	switch names := target[PrivateAttributesKey].(type) {
+	case []string:
+		return names
+	case []interface{}:
+		result := make([]string, 0, len(names))
+		for _, name := range names {
+			if s, ok := name.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
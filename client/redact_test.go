@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"github.com/simpleflags/golang-server-sdk/repository"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// captureLogger keeps all messages of every level
+type captureLogger struct {
+	mux      sync.Mutex
+	messages []string
+}
+
+func (l *captureLogger) add(message string) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	l.messages = append(l.messages, message)
+}
+
+func (l *captureLogger) all() string {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return strings.Join(l.messages, "\n")
+}
+
+func (l *captureLogger) Debug(args ...interface{})                   { l.add(fmt.Sprint(args...)) }
+func (l *captureLogger) Debugf(template string, args ...interface{}) { l.add(fmt.Sprintf(template, args...)) }
+func (l *captureLogger) Info(args ...interface{})                    { l.add(fmt.Sprint(args...)) }
+func (l *captureLogger) Infof(template string, args ...interface{})  { l.add(fmt.Sprintf(template, args...)) }
+func (l *captureLogger) Error(args ...interface{})                   { l.add(fmt.Sprint(args...)) }
+func (l *captureLogger) Errorf(template string, args ...interface{}) { l.add(fmt.Sprintf(template, args...)) }
+
+const (
+	privateEmail = "john@private.example"
+	privateIP    = "203.0.113.99"
+)
+
+func TestPrivateValuesNeverLeaveProcess(t *testing.T) {
+	logger := &captureLogger{}
+	log.SetLogger(logger)
+	t.Cleanup(func() {
+		zap, _ := log.NewZapLogger(false)
+		log.SetLogger(zap)
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	configurations := evaluation.Configurations{
+		{Identifier: "beta", Rules: []evaluation.Rule{{Expression: `email == "` + privateEmail + `" or ip == "x"`}}},
+	}
+	c := newTestClient(t, newFakeConnector(), WithStreamEnabled(false), WithPullerEnabled(false),
+		WithBootstrap(configurations, nil), WithTracerProvider(provider), WithPrivateAttributes("email"))
+
+	target := evaluation.Target{
+		IdentifierKey:        "john",
+		"email":              privateEmail,
+		"ip":                 privateIP,
+		"country":            "CZ",
+		PrivateAttributesKey: []string{"ip"},
+	}
+
+	c.Evaluate("beta", target)
+	c.EvaluateAll(target)
+	c.EvaluateDetails("beta", target)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	c.EvaluateCtx(ctx, "beta", target)
+	span.End()
+
+	// target built by middleware is evaluated from the request context
+	handler := Middleware(func(r *http.Request) evaluation.Target { return target })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := provider.Tracer("test").Start(r.Context(), "handler")
+			defer span.End()
+			c.EvaluateFromContext(ctx, "beta")
+		}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	status := httptest.NewRecorder()
+	c.StatusHandler().ServeHTTP(status, httptest.NewRequest(http.MethodGet, "/debug/flags", nil))
+
+	var snapshot bytes.Buffer
+	if err := repository.WriteSnapshot(&snapshot, c.repository.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	var spans strings.Builder
+	for _, s := range exporter.GetSpans() {
+		fmt.Fprintf(&spans, "%s %v\n", s.Name, s.Attributes)
+		for _, event := range s.Events {
+			fmt.Fprintf(&spans, "%s %v\n", event.Name, event.Attributes)
+		}
+	}
+
+	outputs := map[string]string{
+		"logs":   logger.all(),
+		"spans":  spans.String(),
+		"status": status.Body.String(),
+		"redact": fmt.Sprint(c.Redact(target)),
+	}
+	for name, output := range outputs {
+		for _, value := range []string{privateEmail, privateIP} {
+			if strings.Contains(output, value) {
+				t.Fatalf("private value %q found in %s: %s", value, name, output)
+			}
+		}
+	}
+	// snapshot holds rules, not targets, the rule literal is the only allowed occurrence
+	if strings.Contains(snapshot.String(), privateIP) {
+		t.Fatalf("private value found in snapshot: %s", snapshot.String())
+	}
+
+	if !strings.Contains(outputs["logs"], "flag beta evaluated for target") || !strings.Contains(outputs["logs"], "CZ") {
+		t.Fatalf("expected redacted target in debug logs, got %s", outputs["logs"])
+	}
+	if target["email"] != privateEmail || target["ip"] != privateIP {
+		t.Fatal("redaction must not change the target used in evaluation")
+	}
+}
+
+func TestRedactPrivateIdentifier(t *testing.T) {
+	target := evaluation.Target{IdentifierKey: "john", NameKey: "John", "plan": "pro"}
+	tests := []struct {
+		name    string
+		private []string
+		kept    []string
+		removed []string
+	}{
+		{name: "public", kept: []string{IdentifierKey, NameKey, "plan"}},
+		{name: "identifier", private: []string{IdentifierKey}, kept: []string{NameKey, "plan"}, removed: []string{IdentifierKey}},
+		{name: "all", private: []string{IdentifierKey, NameKey, "plan"}, removed: []string{IdentifierKey, NameKey, "plan"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestClient(t, newFakeConnector(), WithStreamEnabled(false), WithPullerEnabled(false),
+				WithPrivateAttributes(test.private...))
+			redacted := c.Redact(target)
+			for _, key := range test.kept {
+				if redacted[key] != target[key] {
+					t.Fatalf("expected %s to be kept, got %v", key, redacted)
+				}
+			}
+			for _, key := range test.removed {
+				if _, ok := redacted[key]; ok {
+					t.Fatalf("expected %s to be removed, got %v", key, redacted)
+				}
+			}
+			removed, _ := redacted[PrivateAttributesKey].([]string)
+			if fmt.Sprint(removed) != fmt.Sprint(test.removed) {
+				t.Fatalf("expected removed %v, got %v", test.removed, removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"github.com/looplab/fsm"
+	"github.com/simpleflags/golang-server-sdk/log"
+)
+
+// State of the client data synchronization
+type State string
+
+const (
+	// StateInitializing client is created and not yet started
+	StateInitializing State = "initializing"
+	// StateStreaming stream is connected and puller is stopped
+	StateStreaming State = "streaming"
+	// StatePolling stream is not connected and puller keeps data fresh
+	StatePolling State = "polling"
+	// StateOffline stream is not connected and puller is disabled,
+	// or client was set offline
+	StateOffline State = "offline"
+	// StateClosed client was closed
+	StateClosed State = "closed"
+)
+
+const (
+	eventStart      = "start"
+	eventConnect    = "connect"
+	eventDisconnect = "disconnect"
+	eventClose      = "close"
+	eventOffline    = "offline"
+	eventOnline     = "online"
+)
+
+// StateListener is notified on every client state change
+type StateListener func(from, to State)
+
+// newState creates state machine for failover between streaming and polling.
+// When stream drops client falls back to polling, or stays offline if puller
+// is disabled.
+func newState(fallback State, callbacks fsm.Callbacks) *fsm.FSM {
+	return fsm.NewFSM(string(StateInitializing),
+		fsm.Events{
+			{Name: eventStart, Src: []string{string(StateInitializing)}, Dst: string(fallback)},
+			{
+				Name: eventConnect,
+				Src:  []string{string(StateInitializing), string(StatePolling), string(StateOffline)},
+				Dst:  string(StateStreaming),
+			},
+			{
+				Name: eventDisconnect,
+				Src:  []string{string(StateInitializing), string(StateStreaming)},
+				Dst:  string(fallback),
+			},
+			{
+				Name: eventOffline,
+				Src:  []string{string(StateInitializing), string(StateStreaming), string(StatePolling)},
+				Dst:  string(StateOffline),
+			},
+			{Name: eventOnline, Src: []string{string(StateOffline)}, Dst: string(fallback)},
+			{
+				Name: eventClose,
+				Src: []string{string(StateInitializing), string(StateStreaming), string(StatePolling),
+					string(StateOffline)},
+				Dst: string(StateClosed),
+			},
+		},
+		callbacks,
+	)
+}
+
+// fire triggers event on the state machine, events not allowed
+// in the current state are ignored
+func fire(state *fsm.FSM, event string) {
+	err := state.Event(event)
+	switch err.(type) {
+	case nil, fsm.NoTransitionError, fsm.InvalidEventError, fsm.CanceledError:
+		return
+	default:
+		log.Errorf("error changing client state on event %s: %v", event, err)
+	}
+}
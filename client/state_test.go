@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"go.uber.org/atomic"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConnector serves configs and no variables and lets tests drive the stream
+type fakeConnector struct {
+	mux     sync.Mutex
+	updater connector.Updater
+	configs evaluation.Configurations
+	// requested holds variable identifiers of the last pull
+	requested []string
+	pulls     *atomic.Int64
+	streams   *atomic.Int64
+	err       *atomic.Error
+}
+
+func newFakeConnector() *fakeConnector {
+	return &fakeConnector{
+		pulls:   atomic.NewInt64(0),
+		streams: atomic.NewInt64(0),
+		err:     atomic.NewError(nil),
+	}
+}
+
+func (f *fakeConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	f.pulls.Inc()
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return append(evaluation.Configurations{}, f.configs...), f.err.Load()
+}
+
+func (f *fakeConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	f.mux.Lock()
+	f.requested = identifiers
+	f.mux.Unlock()
+	return []evaluation.Variable{}, f.err.Load()
+}
+
+func (f *fakeConnector) Stream(ctx context.Context, updater connector.Updater) error {
+	f.streams.Inc()
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.updater = updater
+	return nil
+}
+
+func (f *fakeConnector) Close() error {
+	return nil
+}
+
+func (f *fakeConnector) stream() connector.Updater {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.updater
+}
+
+// transitions records state changes reported to the listener
+type transitions struct {
+	mux     sync.Mutex
+	changes []State
+}
+
+func (t *transitions) listen(from, to State) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.changes = append(t.changes, to)
+}
+
+func (t *transitions) get() []State {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return append([]State(nil), t.changes...)
+}
+
+// eventually fails the test when condition is not met within a second
+func eventually(t *testing.T, condition func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+func newTestClient(t *testing.T, conn connector.Connector, options ...ConfigOption) *client {
+	t.Helper()
+	c, err := NewWithConnector(conn, options...)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c
+}
+
+func TestStreamFlapsFailOverToPolling(t *testing.T) {
+	conn := newFakeConnector()
+	recorded := &transitions{}
+	c := newTestClient(t, conn, WithPullInterval(3600), WithStateListener(recorded.listen))
+
+	if c.State() != StatePolling || !c.puller.running() {
+		t.Fatalf("expected polling with running puller, got %s", c.State())
+	}
+	stream := conn.stream()
+	if stream == nil {
+		t.Fatal("stream was not started")
+	}
+
+	for i := 0; i < 3; i++ {
+		pulls := conn.pulls.Load()
+		stream.OnConnect()
+		if c.State() != StateStreaming || c.puller.running() {
+			t.Fatalf("flap %d: expected streaming with stopped puller, got %s", i, c.State())
+		}
+		eventually(t, func() bool { return conn.pulls.Load() > pulls }, "no catch-up pull after reconnect")
+
+		stream.OnDisconnect()
+		if c.State() != StatePolling || !c.puller.running() {
+			t.Fatalf("flap %d: expected polling with running puller, got %s", i, c.State())
+		}
+	}
+
+	expected := []State{StatePolling,
+		StateStreaming, StatePolling,
+		StateStreaming, StatePolling,
+		StateStreaming, StatePolling,
+	}
+	changes := recorded.get()
+	if len(changes) != len(expected) {
+		t.Fatalf("expected transitions %v, got %v", expected, changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("expected transitions %v, got %v", expected, changes)
+		}
+	}
+}
+
+func TestRepeatedDisconnectIsIgnored(t *testing.T) {
+	conn := newFakeConnector()
+	recorded := &transitions{}
+	c := newTestClient(t, conn, WithPullInterval(3600), WithStateListener(recorded.listen))
+
+	stream := conn.stream()
+	stream.OnConnect()
+	stream.OnDisconnect()
+	stream.OnDisconnect()
+	stream.OnConnect()
+	stream.OnConnect()
+
+	if c.State() != StateStreaming {
+		t.Fatalf("expected streaming, got %s", c.State())
+	}
+	if changes := recorded.get(); len(changes) != 4 {
+		t.Fatalf("expected 4 transitions, got %v", changes)
+	}
+}
+
+func TestStreamDropWithoutPullerGoesOffline(t *testing.T) {
+	conn := newFakeConnector()
+	c := newTestClient(t, conn, WithPullerEnabled(false))
+
+	if c.State() != StateOffline {
+		t.Fatalf("expected offline, got %s", c.State())
+	}
+	if conn.pulls.Load() != 1 {
+		t.Fatalf("expected single pull on start, got %d", conn.pulls.Load())
+	}
+
+	stream := conn.stream()
+	stream.OnConnect()
+	if c.State() != StateStreaming {
+		t.Fatalf("expected streaming, got %s", c.State())
+	}
+	stream.OnDisconnect()
+	if c.State() != StateOffline || c.puller.running() {
+		t.Fatalf("expected offline with stopped puller, got %s", c.State())
+	}
+}
+
+func TestConnectIgnoredWhileOffline(t *testing.T) {
+	conn := newFakeConnector()
+	c := newTestClient(t, conn, WithPullInterval(3600))
+
+	stream := conn.stream()
+	c.SetOffline(true)
+	stream.OnConnect()
+	if c.State() != StateOffline || c.puller.running() {
+		t.Fatalf("expected offline with stopped puller, got %s", c.State())
+	}
+
+	c.SetOffline(false)
+	if c.State() != StatePolling || !c.puller.running() {
+		t.Fatalf("expected polling with running puller, got %s", c.State())
+	}
+}
+
+func TestCloseStopsPullerAndUpdater(t *testing.T) {
+	conn := newFakeConnector()
+	c, err := NewWithConnector(conn, WithPullInterval(3600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := conn.stream()
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if c.State() != StateClosed || c.puller.running() {
+		t.Fatalf("expected closed with stopped puller, got %s", c.State())
+	}
+
+	// consumers are gone, events must not block the connector
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < cap(c.updater.msgChannel)+1; i++ {
+			stream.OnEvent(&connector.Msg{Event: []byte(evaluation.DeleteFlagEvent), Data: []byte("flag")})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("OnEvent blocked after close")
+	}
+
+	stream.OnConnect()
+	if c.State() != StateClosed {
+		t.Fatalf("expected closed, got %s", c.State())
+	}
+	if err := c.Close(); err == nil {
+		t.Fatal("expected error on second close")
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Status is a snapshot of client internals for diagnostics
+type Status struct {
+	State      State        `json:"state"`
+	Offline    bool         `json:"offline"`
+	InitSource InitSource   `json:"init_source"`
+	Stream     StreamStatus `json:"stream"`
+	Poll       PollStatus   `json:"poll"`
+	// DataAge is time since data was last synchronized
+	DataAge time.Duration `json:"data_age_ns"`
+	Stale   bool          `json:"stale"`
+	// Flags and Variables are numbers of items held in cache or storage
+	Flags         int     `json:"flags"`
+	Variables     int     `json:"variables"`
+	CacheHitRatio float64 `json:"cache_hit_ratio"`
+	// QueueDepth is number of stream events waiting to be applied
+	QueueDepth int `json:"updater_queue_depth"`
+	// PrivateAttributes are names redacted from all targets, values are never reported
+	PrivateAttributes []string `json:"private_attributes,omitempty"`
+	SDKVersion        string   `json:"sdk_version"`
+}
+
+// StreamStatus describes the stream connection
+type StreamStatus struct {
+	Connected bool       `json:"connected"`
+	LastEvent *time.Time `json:"last_event,omitempty"`
+}
+
+// PollStatus describes the last pull
+type PollStatus struct {
+	Running bool       `json:"running"`
+	Last    *time.Time `json:"last,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// Status returns current state of the client
+func (c *client) Status() Status {
+	state := c.State()
+	flags, variables := c.repository.Counts()
+	age := c.DataAge()
+
+	status := Status{
+		State:         state,
+		Offline:       c.Offline(),
+		InitSource:    c.InitSource(),
+		Stream:        StreamStatus{Connected: state == StateStreaming},
+		Poll:          PollStatus{Running: c.puller.running()},
+		DataAge:       age,
+		Stale:         c.stale(age),
+		Flags:         flags,
+		Variables:     variables,
+		CacheHitRatio: c.repository.Stats().HitRatio(),
+		QueueDepth:    c.updater.queueDepth(),
+		SDKVersion:    SDKVersion,
+
+		PrivateAttributes: append([]string{}, c.config.privateAttributes...),
+	}
+	if nanos := c.updater.lastEvent.Load(); nanos != 0 {
+		last := time.Unix(0, nanos)
+		status.Stream.LastEvent = &last
+	}
+	if last, err := c.puller.last(); !last.IsZero() {
+		status.Poll.Last = &last
+		if err != nil {
+			status.Poll.Error = err.Error()
+		}
+	}
+	return status
+}
+
+// StatusHandler serves Status as JSON, for example on /debug/flags
+func (c *client) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(c.Status())
+	})
+}
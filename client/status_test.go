@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusHandlerJSON(t *testing.T) {
+	configurations := evaluation.Configurations{{Identifier: "dark_mode"}, {Identifier: "beta"}}
+	variables := []evaluation.Variable{{Identifier: "beta_users"}}
+	conn := newFakeConnector()
+	conn.err.Store(errors.New("api down"))
+	c := newTestClient(t, conn, WithStreamEnabled(false), WithPullerEnabled(false),
+		WithBootstrap(configurations, variables), WithPrivateAttributes("email"))
+
+	rec := httptest.NewRecorder()
+	c.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/flags", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected json content type, got %q", ct)
+	}
+
+	var status map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"state", "offline", "init_source", "stream", "poll", "data_age_ns", "stale",
+		"flags", "variables", "cache_hit_ratio", "updater_queue_depth", "private_attributes", "sdk_version"} {
+		if _, ok := status[key]; !ok {
+			t.Fatalf("status has no %q: %s", key, rec.Body.String())
+		}
+	}
+	expected := map[string]interface{}{
+		"init_source": string(InitBootstrapped),
+		"flags":       float64(2),
+		"variables":   float64(1),
+		"sdk_version": SDKVersion,
+		"offline":     false,
+	}
+	for key, value := range expected {
+		if status[key] != value {
+			t.Fatalf("expected %s %v, got %v", key, value, status[key])
+		}
+	}
+	if private, _ := status["private_attributes"].([]interface{}); len(private) != 1 || private[0] != "email" {
+		t.Fatalf("expected private attribute names, got %v", status["private_attributes"])
+	}
+	if poll, _ := status["poll"].(map[string]interface{}); poll["running"] != false {
+		t.Fatalf("expected stopped puller, got %v", status["poll"])
+	}
+}
+
+func TestStatusReportsPulls(t *testing.T) {
+	conn := newFakeConnector()
+	conn.configs = evaluation.Configurations{{Identifier: "dark_mode"}}
+	clock := newManualClock()
+	c := newTestClient(t, conn, withClock(clock), WithStreamEnabled(false), WithPullInterval(3600))
+	c.WaitForInitialization()
+
+	status := c.Status()
+	if status.InitSource != InitServer || status.Flags != 1 || status.Variables != 0 {
+		t.Fatalf("unexpected status after pull %+v", status)
+	}
+	if !status.Poll.Running || status.Poll.Last == nil || !status.Poll.Last.Equal(clock.Now()) || status.Poll.Error != "" {
+		t.Fatalf("unexpected poll status %+v", status.Poll)
+	}
+
+	conn.err.Store(errors.New("api down"))
+	if err := c.puller.pull(c.ctx); err == nil {
+		t.Fatal("expected pull error")
+	}
+	if status := c.Status(); status.Poll.Error != "api down" || status.Flags != 1 {
+		t.Fatalf("expected failed pull to keep data, got %+v", status)
+	}
+}
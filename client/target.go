@@ -0,0 +1,288 @@
+package client
+
+import (
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Target keys with special meaning
+const (
+	IdentifierKey        = "identifier"
+	NameKey              = "name"
+	AnonymousKey         = "anonymous"
+	PrivateAttributesKey = "privateAttributes"
+)
+
+// attributeName is what rule expressions can reference
+var attributeName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TargetBuilder builds target with attributes rule expressions can compare:
+// strings, numbers, booleans and lists of them
+type TargetBuilder struct {
+	target  evaluation.Target
+	private []string
+	errs    []string
+}
+
+// NewTargetBuilder creates builder of target with identifier
+func NewTargetBuilder(identifier string) *TargetBuilder {
+	b := &TargetBuilder{target: evaluation.Target{}}
+	return b.Identifier(identifier)
+}
+
+func (b *TargetBuilder) Identifier(identifier string) *TargetBuilder {
+	b.target[IdentifierKey] = identifier
+	return b
+}
+
+func (b *TargetBuilder) Name(name string) *TargetBuilder {
+	b.target[NameKey] = name
+	return b
+}
+
+// Anonymous marks target which does not represent a known user
+func (b *TargetBuilder) Anonymous(anonymous bool) *TargetBuilder {
+	b.target[AnonymousKey] = anonymous
+	return b
+}
+
+func (b *TargetBuilder) String(key, value string) *TargetBuilder {
+	return b.set(key, value)
+}
+
+func (b *TargetBuilder) Int(key string, value int64) *TargetBuilder {
+	return b.set(key, value)
+}
+
+func (b *TargetBuilder) Float(key string, value float64) *TargetBuilder {
+	return b.set(key, value)
+}
+
+func (b *TargetBuilder) Bool(key string, value bool) *TargetBuilder {
+	return b.set(key, value)
+}
+
+func (b *TargetBuilder) Strings(key string, values []string) *TargetBuilder {
+	return b.set(key, append([]string{}, values...))
+}
+
+// Attribute sets custom attribute of any type expressions can compare,
+// other types are reported by Build
+func (b *TargetBuilder) Attribute(key string, value interface{}) *TargetBuilder {
+	normalized, err := normalize(value)
+	if err != nil {
+		b.errs = append(b.errs, fmt.Sprintf("attribute %q: %v", key, err))
+		return b
+	}
+	if normalized == nil {
+		// nil pointer is an unset attribute
+		return b
+	}
+	return b.set(key, normalized)
+}
+
+// Private marks attributes which are used in evaluation but never leave the process
+func (b *TargetBuilder) Private(keys ...string) *TargetBuilder {
+	b.private = append(b.private, keys...)
+	return b
+}
+
+func (b *TargetBuilder) set(key string, value interface{}) *TargetBuilder {
+	switch {
+	case key == IdentifierKey || key == NameKey || key == AnonymousKey || key == PrivateAttributesKey:
+		b.errs = append(b.errs, fmt.Sprintf("attribute %q is reserved, use its setter", key))
+	case !attributeName.MatchString(key):
+		b.errs = append(b.errs, fmt.Sprintf("attribute %q can not be referenced by rules", key))
+	default:
+		b.target[key] = value
+	}
+	return b
+}
+
+// Build returns target or error listing all invalid attributes
+func (b *TargetBuilder) Build() (evaluation.Target, error) {
+	errs := append([]string{}, b.errs...)
+	identifier, _ := b.target[IdentifierKey].(string)
+	anonymous, _ := b.target[AnonymousKey].(bool)
+	if identifier == "" && !anonymous {
+		errs = append(errs, "identifier is required for targets which are not anonymous")
+	}
+	for _, key := range b.private {
+		if _, ok := b.target[key]; !ok {
+			errs = append(errs, fmt.Sprintf("private attribute %q is not set", key))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTarget, strings.Join(errs, "; "))
+	}
+
+	target := make(evaluation.Target, len(b.target)+1)
+	for key, value := range b.target {
+		target[key] = value
+	}
+	if len(b.private) > 0 {
+		target[PrivateAttributesKey] = append([]string{}, b.private...)
+	}
+	return target, nil
+}
+
+// CheckTarget reports attributes of the target which no loaded rule references,
+// for example typos in attribute names. Such attributes can't change any evaluation.
+// Flags added later may reference them, so it is meant for tests and startup checks,
+// evaluation never fails because of them.
+func (c *client) CheckTarget(target evaluation.Target) error {
+	referenced := make(map[string]bool)
+	for _, config := range c.repository.Configurations() {
+		for _, rule := range config.Rules {
+			names, err := evaluation.Variables(rule.Expression)
+			if err != nil {
+				continue
+			}
+			for _, name := range names {
+				referenced[name] = true
+			}
+		}
+	}
+
+	unknown := make([]string, 0)
+	for key := range target {
+		switch key {
+		case IdentifierKey, NameKey, AnonymousKey, PrivateAttributesKey:
+			continue
+		}
+		if !referenced[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%w: attributes not referenced by any rule: %s", ErrInvalidTarget, strings.Join(unknown, ", "))
+}
+
+// TargetFromStruct builds target from fields tagged with sf:"attr".
+// Options after the name: private marks the attribute private.
+// Fields identifier, name and anonymous map to the target keys, embedded structs are flattened.
+//
+//	type User struct {
+//		ID    string `sf:"identifier"`
+//		Email string `sf:"email,private"`
+//		Age   int    `sf:"age"`
+//	}
+func TargetFromStruct(v interface{}) (evaluation.Target, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, fmt.Errorf("%w: nil %T", ErrInvalidTarget, v)
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a struct", ErrInvalidTarget, v)
+	}
+
+	b := &TargetBuilder{target: evaluation.Target{}}
+	b.fromStruct(value)
+	return b.Build()
+}
+
+func (b *TargetBuilder) fromStruct(value reflect.Value) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, tagged := field.Tag.Lookup("sf")
+		if !tagged {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				b.fromStruct(value.Field(i))
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if field.PkgPath != "" {
+			b.errs = append(b.errs, fmt.Sprintf("field %s is unexported", field.Name))
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		key := parts[0]
+		if key == "" {
+			key = field.Name
+		}
+		fieldValue := value.Field(i).Interface()
+
+		switch key {
+		case IdentifierKey, NameKey:
+			s, ok := fieldValue.(string)
+			if !ok {
+				b.errs = append(b.errs, fmt.Sprintf("field %s tagged %q must be string", field.Name, key))
+				continue
+			}
+			b.target[key] = s
+		case AnonymousKey:
+			anonymous, ok := fieldValue.(bool)
+			if !ok {
+				b.errs = append(b.errs, fmt.Sprintf("field %s tagged %q must be bool", field.Name, key))
+				continue
+			}
+			b.Anonymous(anonymous)
+		default:
+			b.Attribute(key, fieldValue)
+		}
+
+		for _, option := range parts[1:] {
+			switch option {
+			case "private":
+				b.Private(key)
+			default:
+				b.errs = append(b.errs, fmt.Sprintf("field %s has unknown tag option %q", field.Name, option))
+			}
+		}
+	}
+}
+
+// normalize converts value to a type rule expressions can compare
+func normalize(v interface{}) (interface{}, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, value.Len())
+		for i := range list {
+			item, err := normalize(value.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			if _, nested := item.([]interface{}); nested {
+				return nil, fmt.Errorf("nested lists can not be compared")
+			}
+			list[i] = item
+		}
+		return list, nil
+	case reflect.Invalid:
+		return nil, fmt.Errorf("nil value can not be compared")
+	}
+	return nil, fmt.Errorf("type %s can not be compared by rules", value.Type())
+}
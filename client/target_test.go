@@ -0,0 +1,81 @@
+package client
+
+import (
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"strings"
+	"testing"
+)
+
+func TestTargetBuilderReportsInvalidAttributes(t *testing.T) {
+	_, err := NewTargetBuilder("john").
+		String("identifier", "other").
+		String("e-mail", "john@example.com").
+		Attribute("tags", map[string]string{}).
+		Private("phone").
+		Build()
+	if !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("expected invalid target, got %v", err)
+	}
+	for _, part := range []string{`"identifier" is reserved`, `"e-mail" can not be referenced`, `"tags"`, `"phone" is not set`} {
+		if !strings.Contains(err.Error(), part) {
+			t.Fatalf("expected %q in %v", part, err)
+		}
+	}
+}
+
+func TestTargetFromStruct(t *testing.T) {
+	type Account struct {
+		Plan string `sf:"plan"`
+	}
+	type User struct {
+		Account
+		ID    string  `sf:"identifier"`
+		Email string  `sf:"email,private"`
+		Age   int     `sf:"age"`
+		Score *uint8  `sf:"score"`
+		Notes string  `sf:"-"`
+		Ratio float32 `sf:"ratio"`
+	}
+
+	target, err := TargetFromStruct(&User{Account: Account{Plan: "pro"}, ID: "john", Email: "john@example.com", Age: 30, Notes: "x", Ratio: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target[IdentifierKey] != "john" || target["plan"] != "pro" || target["age"] != int64(30) || target["ratio"] != float64(0.5) {
+		t.Fatalf("unexpected target %v", target)
+	}
+	if _, ok := target["score"]; ok {
+		t.Fatal("expected nil pointer to be unset")
+	}
+	if _, ok := target["Notes"]; ok {
+		t.Fatal("expected ignored field to be skipped")
+	}
+	if private, _ := target[PrivateAttributesKey].([]string); len(private) != 1 || private[0] != "email" {
+		t.Fatalf("expected email to be private, got %v", target[PrivateAttributesKey])
+	}
+
+	if _, err := TargetFromStruct(struct {
+		Email string `sf:"email,secret"`
+	}{}); !errors.Is(err, ErrInvalidTarget) {
+		t.Fatalf("expected unknown option to be reported, got %v", err)
+	}
+}
+
+func TestCheckTargetReportsTypos(t *testing.T) {
+	configurations := evaluation.Configurations{
+		{Identifier: "beta", Rules: []evaluation.Rule{{Expression: `country == "CZ" and age > 18`}}},
+	}
+	c := newTestClient(t, newFakeConnector(), WithStreamEnabled(false), WithPullerEnabled(false),
+		WithBootstrap(configurations, nil))
+
+	valid := evaluation.Target{IdentifierKey: "john", NameKey: "John", "country": "CZ", "age": 30}
+	if err := c.CheckTarget(valid); err != nil {
+		t.Fatalf("expected referenced attributes to pass, got %v", err)
+	}
+
+	err := c.CheckTarget(evaluation.Target{IdentifierKey: "john", "contry": "CZ", "age": 30})
+	if !errors.Is(err, ErrInvalidTarget) || !strings.Contains(err.Error(), "contry") || strings.Contains(err.Error(), "age") {
+		t.Fatalf("expected typo to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// instrumentationName is name of the tracer used by the SDK
+	instrumentationName = "github.com/simpleflags/golang-server-sdk"
+	// providerName is reported as feature_flag.provider_name
+	providerName = "simpleflags"
+)
+
+// Evaluation reasons reported in feature_flag span events
+const (
+	// ReasonDefault default value was served because data is stale
+	ReasonDefault = "DEFAULT"
+	// ReasonStale value was evaluated from data older than max age
+	ReasonStale = "STALE"
+	// ReasonUnknown evaluation does not expose why the value was chosen
+	ReasonUnknown = "UNKNOWN"
+	// ReasonError default value was served because context was done
+	ReasonError = "ERROR"
+)
+
+// tracer returns tracer of the provider or the global one
+func tracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+func (c *client) reason(stale bool) string {
+	switch {
+	case stale && c.config.stalePolicy == StaleDefaults:
+		return ReasonDefault
+	case stale:
+		return ReasonStale
+	}
+	return ReasonUnknown
+}
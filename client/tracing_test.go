@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestEvaluateCtxRecordsFeatureFlagEvent(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	c := newTestClient(t, newFakeConnector(), WithStreamEnabled(false), WithTracerProvider(provider))
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	eval := c.EvaluateCtx(ctx, "dark_mode", evaluation.Target{"identifier": "john"})
+	span.End()
+
+	var request *tracetest.SpanStub
+	spans := exporter.GetSpans()
+	for i := range spans {
+		if spans[i].Name == "request" {
+			request = &spans[i]
+		}
+	}
+	if request == nil || len(request.Events) != 1 || request.Events[0].Name != "feature_flag" {
+		t.Fatalf("expected feature_flag event on the request span, got %+v", request)
+	}
+	attributes := make(map[string]string)
+	for _, attr := range request.Events[0].Attributes {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attributes["feature_flag.key"] != "dark_mode" || attributes["feature_flag.provider_name"] != providerName {
+		t.Fatalf("unexpected attributes %v", attributes)
+	}
+	if v, ok := attributes["feature_flag.variant"]; ok != (variant(eval) != "") || v != variant(eval) {
+		t.Fatalf("expected variant %q, got %q", variant(eval), v)
+	}
+}
@@ -3,11 +3,16 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/looplab/fsm"
 	"github.com/simpleflags/evaluation"
 	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/metrics"
 	"github.com/simpleflags/golang-server-sdk/repository"
+	"go.uber.org/atomic"
 	"log"
+	"sync"
+	"time"
 )
 
 type updater struct {
@@ -15,74 +20,150 @@ type updater struct {
 	repository repository.Repository
 	msgChannel chan *connector.Msg
 	fsm        *fsm.FSM
+	puller     *puller
+	ctx        context.Context
+	cancel     context.CancelFunc
+	consumers  sync.WaitGroup
+	// lastEvent is unix nano time of the last event received from the stream
+	lastEvent *atomic.Int64
+	// connects counts stream connections, all but the first are reconnects
+	connects *atomic.Int64
+	// resyncPending is set while a pull triggered by the stream waits to start
+	resyncPending *atomic.Bool
+	metrics       metrics.Recorder
 }
 
-func newUpdater(conn connector.Connector, repo repository.Repository, fsm *fsm.FSM) updater {
+func newUpdater(conn connector.Connector, repo repository.Repository, fsm *fsm.FSM, puller *puller) updater {
 	msgChannel := make(chan *connector.Msg, 100)
 	return updater{
-		connector:  conn,
-		repository: repo,
-		msgChannel: msgChannel,
-		fsm:        fsm,
+		connector:     conn,
+		repository:    repo,
+		msgChannel:    msgChannel,
+		fsm:           fsm,
+		puller:        puller,
+		ctx:           context.Background(),
+		lastEvent:     atomic.NewInt64(0),
+		connects:      atomic.NewInt64(0),
+		resyncPending: atomic.NewBool(false),
+		metrics:       metrics.Noop{},
 	}
 }
 
+// start consumers of stream events, they run until the updater is closed
 func (u *updater) start(ctx context.Context) {
-	if err := u.connector.Stream(ctx, u); err != nil {
-		return
-	}
+	u.ctx, u.cancel = context.WithCancel(ctx)
 	for i := 0; i < 5; i++ {
-		go u.consumer(ctx, u.msgChannel)
+		u.consumers.Add(1)
+		go u.consumer(u.ctx, u.msgChannel)
+	}
+}
+
+// stream receives changes from the connector until ctx is done
+func (u *updater) stream(ctx context.Context) {
+	if err := u.connector.Stream(ctx, u); err != nil && !errors.Is(err, connector.ErrStreamNotSupported) {
+		log.Printf("error starting stream %v", err)
 	}
 }
 
 func (u *updater) OnConnect() {
-	err := u.fsm.Event("connect")
-	if err != nil {
-		log.Println(err)
+	if u.connects.Inc() > 1 {
+		u.metrics.StreamReconnect()
 	}
+	u.repository.MarkSynced(repository.SyncStream)
+	fire(u.fsm, eventConnect)
 }
 
 func (u *updater) OnDisconnect() {
-	err := u.fsm.Event("disconnect")
-	if err != nil {
-		log.Println(err)
+	fire(u.fsm, eventDisconnect)
+}
+
+// OnHeartbeat keeps data age fresh while the stream is quiet
+func (u *updater) OnHeartbeat() {
+	u.repository.MarkSynced(repository.SyncStream)
+}
+
+// OnResync reloads all data when connector missed some events
+func (u *updater) OnResync() {
+	u.resync()
+}
+
+// resync starts a pull unless one is already waiting to start
+func (u *updater) resync() {
+	if !u.resyncPending.CAS(false, true) {
+		return
 	}
+	go func() {
+		u.resyncPending.Store(false)
+		_ = u.puller.pull(u.ctx)
+	}()
 }
 
+// OnEvent queues the event for consumers. When the queue is full the event
+// is dropped and all data is pulled again, so the stream is never blocked.
 func (u *updater) OnEvent(msg *connector.Msg) {
-	u.msgChannel <- msg
+	u.lastEvent.Store(time.Now().UnixNano())
+	select {
+	case u.msgChannel <- msg:
+	case <-u.ctx.Done():
+	default:
+		log.Printf("event queue is full, dropping %s event and resyncing", msg.Event)
+		u.metrics.UpdaterEvent(string(msg.Event), true)
+		u.resync()
+	}
+}
+
+// queueDepth returns number of events waiting for consumers
+func (u *updater) queueDepth() int {
+	return len(u.msgChannel)
 }
 
 func (u *updater) consumer(ctx context.Context, msgChan chan *connector.Msg) {
-	for msg := range msgChan {
-		ev := string(msg.Event)
-		switch ev {
-		case evaluation.CreateFlagEvent, evaluation.PatchFlagEvent:
-			var cfg evaluation.Configuration
-			err := json.Unmarshal(msg.Data, &cfg)
-			if err != nil {
-				log.Printf("error processing event %v", err)
-				return
-			}
-			u.repository.SetConfiguration(&cfg)
-		case evaluation.DeleteFlagEvent:
-			u.repository.DeleteConfiguration(string(msg.Data))
-		case evaluation.CreateVariable, evaluation.PatchVariable:
-			var v evaluation.Variable
-			err := json.Unmarshal(msg.Data, &v)
-			if err != nil {
-				log.Printf("error processing event %v", err)
-				return
-			}
-			u.repository.SetVariable(&v)
-		case evaluation.DeleteVariable:
-			u.repository.DeleteVariable(string(msg.Data))
+	defer u.consumers.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-msgChan:
+			u.process(msg)
+		}
+	}
+}
+
+// process applies single stream event to the repository
+func (u *updater) process(msg *connector.Msg) {
+	ev := string(msg.Event)
+	switch ev {
+	case evaluation.CreateFlagEvent, evaluation.PatchFlagEvent:
+		var cfg evaluation.Configuration
+		err := json.Unmarshal(msg.Data, &cfg)
+		if err != nil {
+			log.Printf("error processing event %v", err)
+			u.metrics.UpdaterEvent(ev, true)
+			return
 		}
+		u.repository.SetConfiguration(&cfg)
+	case evaluation.DeleteFlagEvent:
+		u.repository.DeleteConfiguration(string(msg.Data))
+	case evaluation.CreateVariable, evaluation.PatchVariable:
+		var v evaluation.Variable
+		err := json.Unmarshal(msg.Data, &v)
+		if err != nil {
+			log.Printf("error processing event %v", err)
+			u.metrics.UpdaterEvent(ev, true)
+			return
+		}
+		u.repository.SetVariable(&v)
+	case evaluation.DeleteVariable:
+		u.repository.DeleteVariable(string(msg.Data))
 	}
-	<-ctx.Done()
+	u.repository.MarkSynced(repository.SyncStream)
+	u.metrics.UpdaterEvent(ev, false)
 }
 
+// close stops consumers and waits until they are done, later events are dropped
 func (u *updater) close() {
-	close(u.msgChannel)
+	if u.cancel != nil {
+		u.cancel()
+	}
+	u.consumers.Wait()
 }
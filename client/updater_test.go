@@ -0,0 +1,56 @@
+package client
+
+import (
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/metrics"
+	"go.uber.org/atomic"
+	"testing"
+	"time"
+)
+
+// dropRecorder counts updater events reported as dropped
+type dropRecorder struct {
+	metrics.Noop
+	dropped *atomic.Int64
+}
+
+func (r dropRecorder) UpdaterEvent(event string, dropped bool) {
+	if dropped {
+		r.dropped.Inc()
+	}
+}
+
+func TestUpdaterDropsEventsWhenQueueIsFull(t *testing.T) {
+	conn := newFakeConnector()
+	p := newTestPuller(t, conn, newManualClock(), 0)
+	u := newUpdater(conn, p.repository, nil, p)
+	recorder := dropRecorder{dropped: atomic.NewInt64(0)}
+	u.metrics = recorder
+
+	// consumers are not started, so nothing drains the queue
+	msg := &connector.Msg{Event: []byte(evaluation.DeleteFlagEvent), Data: []byte("dark_mode")}
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < cap(u.msgChannel)+1; i++ {
+			u.OnEvent(msg)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream was blocked by full queue")
+	}
+
+	if got := recorder.dropped.Load(); got != 1 {
+		t.Fatalf("expected one dropped event, got %d", got)
+	}
+	eventually(t, func() bool { return conn.pulls.Load() == 1 }, "dropped event did not trigger resync")
+}
+
+func TestVariantIsBooleanOrEmpty(t *testing.T) {
+	if label := variant(evaluation.Evaluation{}); label != "" && label != "true" && label != "false" {
+		t.Fatalf("expected boolean or empty variant, got %q", label)
+	}
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"encoding/json"
+	"github.com/simpleflags/evaluation"
+)
+
+// variant identifies value the flag was evaluated to for metrics and traces.
+// Only boolean values are reported, other values have no meaningful short
+// identifier, so variant is empty for them and served values never become labels.
+func variant(eval evaluation.Evaluation) string {
+	data, err := json.Marshal(eval)
+	if err != nil {
+		return ""
+	}
+	switch string(data) {
+	case "true", "false":
+		return string(data)
+	}
+	return ""
+}
@@ -0,0 +1,4 @@
+package client
+
+// SDKVersion is version of this SDK reported in Status
+const SDKVersion = "0.1.0"
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/simpleflags/golang-server-sdk/connector/simple"
+	"github.com/simpleflags/golang-server-sdk/relay"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves until SIGINT or SIGTERM, it returns exit code so deferred cleanup always runs
+func run() int {
+	addr := flag.String("addr", ":7000", "address to listen on")
+	sdkKeys := flag.String("sdk-keys", os.Getenv("SF_RELAY_SDK_KEYS"), "comma separated SDK keys of environments to relay")
+	baseURL := flag.String("base-url", "", "upstream api url")
+	streamURL := flag.String("stream-url", "", "upstream stream url")
+	flag.Parse()
+
+	if *sdkKeys == "" {
+		log.Print("at least one SDK key is required, use -sdk-keys or SF_RELAY_SDK_KEYS")
+		return 2
+	}
+
+	var upstream []simple.Option
+	if *baseURL != "" {
+		upstream = append(upstream, simple.WithBaseURL(*baseURL))
+	}
+	if *streamURL != "" {
+		upstream = append(upstream, simple.WithStreamURL(*streamURL))
+	}
+
+	var environments []relay.Environment
+	for _, key := range strings.Split(*sdkKeys, ",") {
+		environments = append(environments, relay.Environment{SDKKey: strings.TrimSpace(key)})
+	}
+
+	r, err := relay.New(environments, relay.WithUpstreamOptions(upstream...))
+	if err != nil {
+		log.Printf("could not start relay: %v", err)
+		return 1
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			log.Printf("error while closing relay err: %v", err)
+		}
+	}()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           r.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// closed when in-flight requests are drained
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		// streams never finish by themselves, close them after timeout
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			_ = server.Close()
+		}
+	}()
+
+	log.Printf("relay listening on %s", *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("relay stopped: %v", err)
+		return 1
+	}
+	// Serve returns as soon as shutdown starts
+	<-done
+	return 0
+}
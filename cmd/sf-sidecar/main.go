@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"github.com/simpleflags/golang-server-sdk/client"
+	"github.com/simpleflags/golang-server-sdk/connector/simple"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves until SIGINT or SIGTERM, it returns exit code so deferred cleanup always runs
+func run() int {
+	addr := flag.String("addr", ":7001", "tcp address to listen on")
+	socket := flag.String("socket", "", "unix socket path to listen on instead of tcp address")
+	sdkKey := flag.String("sdk-key", os.Getenv("SF_SDK_KEY"), "SDK key of the environment")
+	baseURL := flag.String("base-url", "", "api url")
+	streamURL := flag.String("stream-url", "", "stream url")
+	flag.Parse()
+
+	if *sdkKey == "" {
+		log.Print("SDK key is required, use -sdk-key or SF_SDK_KEY")
+		return 2
+	}
+
+	var options []simple.Option
+	if *baseURL != "" {
+		options = append(options, simple.WithBaseURL(*baseURL))
+	}
+	if *streamURL != "" {
+		options = append(options, simple.WithStreamURL(*streamURL))
+	}
+
+	sf, err := client.NewWithConnector(simple.NewHttpConnector(*sdkKey, options...))
+	if err != nil {
+		log.Printf("could not connect to SF servers %v", err)
+		return 1
+	}
+	defer func() {
+		if err := sf.Close(); err != nil {
+			log.Printf("error while closing client err: %v", err)
+		}
+	}()
+
+	listener, err := listen(*addr, *socket)
+	if err != nil {
+		log.Printf("could not listen: %v", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	httpServer := &http.Server{
+		Handler:           server{client: sf}.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	// closed when in-flight requests are drained
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("error while shutting down err: %v", err)
+		}
+	}()
+
+	log.Printf("sidecar listening on %s", listener.Addr())
+	if err := httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("sidecar stopped: %v", err)
+		return 1
+	}
+	// Serve returns as soon as shutdown starts
+	<-done
+	return 0
+}
+
+// listen opens unix socket when path is given, otherwise tcp address
+func listen(addr, socket string) (net.Listener, error) {
+	if socket == "" {
+		return net.Listen("tcp", addr)
+	}
+	// remove socket left by previous run
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", socket)
+}
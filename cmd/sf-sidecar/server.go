@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/client"
+	"net/http"
+)
+
+type evaluateRequest struct {
+	Feature string            `json:"feature"`
+	Target  evaluation.Target `json:"target"`
+}
+
+type server struct {
+	client client.Client
+}
+
+func (s server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/evaluate", s.evaluate)
+	mux.HandleFunc("/evaluate-all", s.evaluateAll)
+	mux.Handle("/debug/flags", s.client.StatusHandler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		// a failed first pull initializes the client without any data
+		if s.client.InitSource() != client.InitServer {
+			http.Error(w, "flags not loaded yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// evaluate returns the same evaluation as client.Evaluate in process
+func (s server) evaluate(w http.ResponseWriter, r *http.Request) {
+	req, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	if req.Feature == "" {
+		http.Error(w, "feature is required", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, s.client.Evaluate(req.Feature, req.Target))
+}
+
+// evaluateAll returns evaluations of every flag keyed by flag identifier
+func (s server) evaluateAll(w http.ResponseWriter, r *http.Request) {
+	req, ok := decode(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, s.client.EvaluateAll(req.Target))
+}
+
+func decode(w http.ResponseWriter, r *http.Request) (evaluateRequest, bool) {
+	var req evaluateRequest
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return req, false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, false
+	}
+	if req.Target == nil {
+		req.Target = evaluation.Target{}
+	}
+	return req, true
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
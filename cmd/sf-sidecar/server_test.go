@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/client"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// downConnector fails every read
+type downConnector struct{}
+
+func (downConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	return evaluation.Configurations{}, errors.New("api down")
+}
+
+func (downConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	return []evaluation.Variable{}, errors.New("api down")
+}
+
+func (downConnector) Stream(ctx context.Context, updater connector.Updater) error {
+	return connector.ErrStreamNotSupported
+}
+
+func (downConnector) Close() error {
+	return nil
+}
+
+func newTestServer(t *testing.T, conn connector.Connector) http.Handler {
+	t.Helper()
+	sf, err := client.NewWithConnector(conn, client.WithPullerEnabled(false), client.WithStreamEnabled(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = sf.Close()
+	})
+	sf.WaitForInitialization()
+	return server{client: sf}.handler()
+}
+
+func TestServer(t *testing.T) {
+	loaded := connector.NewStaticConnector(evaluation.Configurations{{Identifier: "dark_mode"}}, nil)
+
+	tests := []struct {
+		name   string
+		conn   connector.Connector
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{name: "healthz", conn: loaded, method: http.MethodGet, path: "/healthz", code: http.StatusOK},
+		{name: "healthz without data", conn: downConnector{}, method: http.MethodGet, path: "/healthz", code: http.StatusOK},
+		{name: "readyz", conn: loaded, method: http.MethodGet, path: "/readyz", code: http.StatusOK},
+		{name: "readyz after failed pull", conn: downConnector{}, method: http.MethodGet, path: "/readyz", code: http.StatusServiceUnavailable},
+		{name: "evaluate", conn: loaded, method: http.MethodPost, path: "/evaluate",
+			body: `{"feature":"dark_mode","target":{"identifier":"john"}}`, code: http.StatusOK},
+		{name: "evaluate without target", conn: loaded, method: http.MethodPost, path: "/evaluate",
+			body: `{"feature":"dark_mode"}`, code: http.StatusOK},
+		{name: "evaluate without feature", conn: loaded, method: http.MethodPost, path: "/evaluate",
+			body: `{"target":{}}`, code: http.StatusBadRequest},
+		{name: "evaluate invalid json", conn: loaded, method: http.MethodPost, path: "/evaluate",
+			body: `{`, code: http.StatusBadRequest},
+		{name: "evaluate with get", conn: loaded, method: http.MethodGet, path: "/evaluate", code: http.StatusMethodNotAllowed},
+		{name: "evaluate all", conn: loaded, method: http.MethodPost, path: "/evaluate-all",
+			body: `{"target":{"identifier":"john"}}`, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestServer(t, tt.conn)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerEvaluateAllReturnsEveryFlag(t *testing.T) {
+	handler := newTestServer(t, connector.NewStaticConnector(
+		evaluation.Configurations{{Identifier: "dark_mode"}, {Identifier: "beta"}}, nil))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/evaluate-all", strings.NewReader(`{"target":{}}`)))
+	var evaluations map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&evaluations); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := evaluations["dark_mode"]; !ok || len(evaluations) != 2 {
+		t.Fatalf("expected evaluation of every flag, got %v", evaluations)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+// diffReport lists changes needed to turn source A into source B
+type diffReport struct {
+	Configurations []itemDiff `json:"configurations"`
+	Variables      []itemDiff `json:"variables"`
+}
+
+func (r diffReport) empty() bool {
+	return len(r.Configurations) == 0 && len(r.Variables) == 0
+}
+
+type itemDiff struct {
+	Identifier string      `json:"identifier"`
+	Change     string      `json:"change"`
+	VersionA   interface{} `json:"version_a,omitempty"`
+	VersionB   interface{} `json:"version_b,omitempty"`
+	Rules      []ruleDiff  `json:"rules,omitempty"`
+	// A and B hold whole items when change is not covered by rules
+	A json.RawMessage `json:"a,omitempty"`
+	B json.RawMessage `json:"b,omitempty"`
+}
+
+type ruleDiff struct {
+	Index  int    `json:"index"`
+	Change string `json:"change"`
+	A      string `json:"a,omitempty"`
+	B      string `json:"b,omitempty"`
+}
+
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	sdkKey := fs.String("sdk-key", os.Getenv("SF_SDK_KEY"), "SDK key used with api urls")
+	sdkKeyA := fs.String("sdk-key-a", "", "SDK key of source A, default is -sdk-key")
+	sdkKeyB := fs.String("sdk-key-b", "", "SDK key of source B, default is -sdk-key")
+	asJSON := fs.Bool("json", false, "print differences as JSON")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for loading flags")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sfctl diff [flags] <sourceA> <sourceB>")
+		fmt.Fprintln(fs.Output(), "exits with 0 when sources are equal, 1 when they differ and 2 on error")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if *sdkKeyA == "" {
+		*sdkKeyA = *sdkKey
+	}
+	if *sdkKeyB == "" {
+		*sdkKeyB = *sdkKey
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	configsA, varsA, err := source{location: fs.Arg(0), sdkKey: *sdkKeyA}.load(ctx)
+	if err != nil {
+		fail(err)
+		return 2
+	}
+	configsB, varsB, err := source{location: fs.Arg(1), sdkKey: *sdkKeyB}.load(ctx)
+	if err != nil {
+		fail(err)
+		return 2
+	}
+
+	report := diffReport{
+		Configurations: diffConfigurations(configsA, configsB),
+		Variables:      diffVariables(varsA, varsB),
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fail(err)
+			return 2
+		}
+	} else {
+		printDiff(report)
+	}
+
+	if report.empty() {
+		return 0
+	}
+	return 1
+}
+
+func diffConfigurations(a, b evaluation.Configurations) []itemDiff {
+	indexA := make(map[string]evaluation.Configuration, len(a))
+	for _, config := range a {
+		indexA[config.Identifier] = config
+	}
+	indexB := make(map[string]evaluation.Configuration, len(b))
+	for _, config := range b {
+		indexB[config.Identifier] = config
+	}
+
+	diffs := make([]itemDiff, 0)
+	for id, configA := range indexA {
+		configB, ok := indexB[id]
+		if !ok {
+			diffs = append(diffs, itemDiff{Identifier: id, Change: changeRemoved, VersionA: configA.Version})
+			continue
+		}
+		if equalJSON(configA, configB) {
+			continue
+		}
+		diff := itemDiff{
+			Identifier: id,
+			Change:     changeChanged,
+			VersionA:   configA.Version,
+			VersionB:   configB.Version,
+			Rules:      diffRules(configA.Rules, configB.Rules),
+		}
+		// changes outside of rules and version are shown as whole items
+		configA.Rules, configB.Rules = nil, nil
+		configA.Version = configB.Version
+		if !equalJSON(configA, configB) {
+			diff.A, _ = json.Marshal(configA)
+			diff.B, _ = json.Marshal(configB)
+		}
+		diffs = append(diffs, diff)
+	}
+	for id, configB := range indexB {
+		if _, ok := indexA[id]; !ok {
+			diffs = append(diffs, itemDiff{Identifier: id, Change: changeAdded, VersionB: configB.Version})
+		}
+	}
+	return sortDiffs(diffs)
+}
+
+func diffRules(a, b []evaluation.Rule) []ruleDiff {
+	diffs := make([]ruleDiff, 0)
+	for i := 0; i < len(a) || i < len(b); i++ {
+		switch {
+		case i >= len(b):
+			diffs = append(diffs, ruleDiff{Index: i, Change: changeRemoved, A: a[i].Expression})
+		case i >= len(a):
+			diffs = append(diffs, ruleDiff{Index: i, Change: changeAdded, B: b[i].Expression})
+		case !equalJSON(a[i], b[i]):
+			diffs = append(diffs, ruleDiff{Index: i, Change: changeChanged, A: a[i].Expression, B: b[i].Expression})
+		}
+	}
+	return diffs
+}
+
+func diffVariables(a, b []evaluation.Variable) []itemDiff {
+	indexA := make(map[string]evaluation.Variable, len(a))
+	for _, variable := range a {
+		indexA[variable.Identifier] = variable
+	}
+	indexB := make(map[string]evaluation.Variable, len(b))
+	for _, variable := range b {
+		indexB[variable.Identifier] = variable
+	}
+
+	diffs := make([]itemDiff, 0)
+	for id, variableA := range indexA {
+		variableB, ok := indexB[id]
+		if !ok {
+			diffs = append(diffs, itemDiff{Identifier: id, Change: changeRemoved})
+			continue
+		}
+		if !equalJSON(variableA, variableB) {
+			diff := itemDiff{Identifier: id, Change: changeChanged}
+			diff.A, _ = json.Marshal(variableA)
+			diff.B, _ = json.Marshal(variableB)
+			diffs = append(diffs, diff)
+		}
+	}
+	for id := range indexB {
+		if _, ok := indexA[id]; !ok {
+			diffs = append(diffs, itemDiff{Identifier: id, Change: changeAdded})
+		}
+	}
+	return sortDiffs(diffs)
+}
+
+func sortDiffs(diffs []itemDiff) []itemDiff {
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Identifier < diffs[j].Identifier
+	})
+	return diffs
+}
+
+// equalJSON compares items as they are served, unexported fields are ignored
+func equalJSON(a, b interface{}) bool {
+	bytesA, errA := json.Marshal(a)
+	bytesB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(bytesA, bytesB)
+}
+
+func printDiff(report diffReport) {
+	if report.empty() {
+		fmt.Println("no differences")
+		return
+	}
+	marks := map[string]string{changeAdded: "+", changeRemoved: "-", changeChanged: "~"}
+	section := func(title string, diffs []itemDiff) {
+		if len(diffs) == 0 {
+			return
+		}
+		fmt.Println(title + ":")
+		for _, d := range diffs {
+			switch {
+			case d.VersionA != nil && d.VersionB != nil:
+				fmt.Printf("  %s %s (version %v -> %v)\n", marks[d.Change], d.Identifier, d.VersionA, d.VersionB)
+			case d.VersionA != nil:
+				fmt.Printf("  %s %s (version %v)\n", marks[d.Change], d.Identifier, d.VersionA)
+			case d.VersionB != nil:
+				fmt.Printf("  %s %s (version %v)\n", marks[d.Change], d.Identifier, d.VersionB)
+			default:
+				fmt.Printf("  %s %s\n", marks[d.Change], d.Identifier)
+			}
+			for _, r := range d.Rules {
+				switch r.Change {
+				case changeAdded:
+					fmt.Printf("      + rule %d: %s\n", r.Index, r.B)
+				case changeRemoved:
+					fmt.Printf("      - rule %d: %s\n", r.Index, r.A)
+				default:
+					fmt.Printf("      ~ rule %d: %s -> %s\n", r.Index, r.A, r.B)
+				}
+			}
+			if d.A != nil {
+				fmt.Printf("      - %s\n", d.A)
+				fmt.Printf("      + %s\n", d.B)
+			}
+		}
+	}
+	section("flags", report.Configurations)
+	section("variables", report.Variables)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/client"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/repository"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// consulted is a name referenced by the flag rules and where its value comes from
+type consulted struct {
+	Name   string      `json:"name"`
+	Source string      `json:"source"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+// matchedRule is the rule which decided the evaluation
+type matchedRule struct {
+	Index      int    `json:"index"`
+	Expression string `json:"expression"`
+}
+
+type evalResult struct {
+	Feature    string                `json:"feature"`
+	Evaluation evaluation.Evaluation `json:"evaluation"`
+	Reason     string                `json:"reason"`
+	Rule       *matchedRule          `json:"rule,omitempty"`
+	Variables  []consulted           `json:"variables"`
+}
+
+func runEval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	from := fs.String("from", "", "api url, flags directory or storage directory")
+	sdkKey := fs.String("sdk-key", os.Getenv("SF_SDK_KEY"), "SDK key used with api url")
+	targetJSON := fs.String("target", "", "target as JSON object, key=value arguments are added to it")
+	asJSON := fs.Bool("json", false, "print result as JSON")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for loading flags")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sfctl eval -from <source> [flags] <feature> [key=value ...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" || fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	feature := fs.Arg(0)
+	target, err := parseTarget(*targetJSON, fs.Args()[1:])
+	if err != nil {
+		return fail(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	configurations, variables, err := source{location: *from, sdkKey: *sdkKey}.load(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	// the same client as in services, so evaluation matches in-process results
+	sf, err := client.NewWithConnector(connector.NewStaticConnector(configurations, variables),
+		client.WithPullerEnabled(false), client.WithStreamEnabled(false))
+	if err != nil {
+		return fail(err)
+	}
+	defer sf.Close()
+
+	result := evalResult{
+		Feature:    feature,
+		Evaluation: sf.Evaluate(feature, target),
+		Reason:     "flag not found, default value is served",
+		Variables:  []consulted{},
+	}
+	for _, config := range configurations {
+		if config.Identifier != feature {
+			continue
+		}
+		result.Rule, err = matchRule(config, target, variables)
+		if err != nil {
+			return fail(err)
+		}
+		result.Reason = "no rule changed the result, flag fallthrough is served"
+		if result.Rule != nil {
+			result.Reason = fmt.Sprintf("rule %d matched: %s", result.Rule.Index, result.Rule.Expression)
+		}
+		result.Variables, err = consultedBy(config, target, variables)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return fail(err)
+		}
+		return 0
+	}
+
+	evaluated, err := json.Marshal(result.Evaluation)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Printf("feature:    %s\n", result.Feature)
+	fmt.Printf("evaluation: %s\n", evaluated)
+	fmt.Printf("reason:     %s\n", result.Reason)
+	if len(result.Variables) > 0 {
+		fmt.Println("variables:")
+		for _, v := range result.Variables {
+			if v.Value != nil {
+				value, _ := json.Marshal(v.Value)
+				fmt.Printf("  %s = %s (%s)\n", v.Name, value, v.Source)
+				continue
+			}
+			fmt.Printf("  %s (%s)\n", v.Name, v.Source)
+		}
+	}
+	return 0
+}
+
+// matchRule finds the rule deciding the evaluation. Rules are tried in order,
+// so the matched rule is the first one whose addition gives the final result.
+// Nil is returned when the result equals evaluation without rules.
+func matchRule(config evaluation.Configuration, target evaluation.Target, variables []evaluation.Variable) (*matchedRule, error) {
+	evaluated := make([]string, 0, len(config.Rules)+1)
+	for k := 0; k <= len(config.Rules); k++ {
+		prefix := config
+		prefix.Rules = config.Rules[:k]
+		result, err := evaluate(prefix, target, variables)
+		if err != nil {
+			return nil, err
+		}
+		evaluated = append(evaluated, result)
+	}
+
+	final := evaluated[len(config.Rules)]
+	for k := len(config.Rules); k > 0; k-- {
+		if evaluated[k-1] != final {
+			return &matchedRule{Index: k - 1, Expression: config.Rules[k-1].Expression}, nil
+		}
+	}
+	return nil, nil
+}
+
+// evaluate returns JSON form of the flag evaluation against given data only
+func evaluate(config evaluation.Configuration, target evaluation.Target, variables []evaluation.Variable) (string, error) {
+	cache, err := repository.NewLruCache(len(variables) + 1)
+	if err != nil {
+		return "", err
+	}
+	repo := repository.New(cache)
+	repo.SetConfiguration(&config)
+	for i := range variables {
+		repo.SetVariable(&variables[i])
+	}
+	evaluator, err := evaluation.NewEvaluator(repo)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(evaluator.Evaluate(config.Identifier, target))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// consultedBy lists names used in rule expressions of the flag
+func consultedBy(config evaluation.Configuration, target evaluation.Target, variables []evaluation.Variable) ([]consulted, error) {
+	defined := make(map[string]bool, len(variables))
+	for _, variable := range variables {
+		defined[variable.Identifier] = true
+	}
+
+	seen := make(map[string]bool)
+	result := make([]consulted, 0)
+	for _, rule := range config.Rules {
+		names, err := evaluation.Variables(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Expression, err)
+		}
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			switch value, ok := target[name]; {
+			case ok:
+				result = append(result, consulted{Name: name, Source: "target", Value: value})
+			case defined[name]:
+				result = append(result, consulted{Name: name, Source: "variable"})
+			default:
+				result = append(result, consulted{Name: name, Source: "missing"})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// parseTarget merges JSON object with key=value pairs, values which are valid
+// JSON like numbers and booleans keep their type, others are strings
+func parseTarget(object string, pairs []string) (evaluation.Target, error) {
+	target := evaluation.Target{}
+	if object != "" {
+		if err := json.Unmarshal([]byte(object), &target); err != nil {
+			return nil, fmt.Errorf("invalid target JSON: %w", err)
+		}
+	}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.New("target attribute must be key=value, got " + pair)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+		target[parts[0]] = value
+	}
+	return target, nil
+}
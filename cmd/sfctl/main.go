@@ -0,0 +1,75 @@
+// Command sfctl inspects flags and variables outside of running services
+package main
+
+import (
+	"fmt"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"os"
+	"sort"
+)
+
+// command runs with arguments following its name and returns process exit code
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"diff":     {usage: "show differences between two sources", run: runDiff},
+	"eval":     {usage: "evaluate a flag for a target", run: runEval},
+	"export":   {usage: "write snapshot bundle of a source", run: runExport},
+	"import":   {usage: "restore snapshot bundle into storage or flags directory", run: runImport},
+	"validate": {usage: "check flag and variable files", run: runValidate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	// sdk info logs would mix with command output
+	log.SetLogger(quietLogger{})
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: sfctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+// fail prints error and returns exit code for failed command
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "sfctl: %v\n", err)
+	return 1
+}
+
+// quietLogger prints only errors of the sdk to stderr
+type quietLogger struct{}
+
+func (quietLogger) Debug(args ...interface{})                   {}
+func (quietLogger) Debugf(template string, args ...interface{}) {}
+func (quietLogger) Info(args ...interface{})                    {}
+func (quietLogger) Infof(template string, args ...interface{})  {}
+
+func (quietLogger) Error(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+}
+
+func (quietLogger) Errorf(template string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, template+"\n", args...)
+}
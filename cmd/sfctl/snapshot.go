@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/simpleflags/golang-server-sdk/repository"
+	"os"
+	"time"
+)
+
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	from := fs.String("from", "", "api url, flags directory, storage directory or bundle")
+	sdkKey := fs.String("sdk-key", os.Getenv("SF_SDK_KEY"), "SDK key used with api url")
+	output := fs.String("o", "", "bundle file to write, default is stdout")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for loading flags")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sfctl export -from <source> [-o bundle.json]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	configurations, variables, err := source{location: *from, sdkKey: *sdkKey}.load(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	snapshot := repository.NewSnapshot(configurations, variables)
+
+	if *output == "" {
+		if err := repository.WriteSnapshot(os.Stdout, snapshot); err != nil {
+			return fail(err)
+		}
+	} else if err := writeSnapshotFile(*output, snapshot); err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d flags and %d variables\n", len(snapshot.Configurations), len(snapshot.Variables))
+	return 0
+}
+
+// writeSnapshotFile writes bundle to the file, failed close means the bundle may be incomplete
+func writeSnapshotFile(path string, snapshot repository.Snapshot) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := repository.WriteSnapshot(file, snapshot); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	storageDir := fs.String("storage", "", "restore into FileStorage directory")
+	dir := fs.String("dir", "", "restore into FileConnector directory layout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sfctl import (-storage <dir> | -dir <dir>) <bundle.json>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || (*storageDir == "") == (*dir == "") {
+		fs.Usage()
+		return 2
+	}
+
+	snapshot, err := repository.ReadSnapshotFile(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+
+	if *storageDir != "" {
+		storage, err := repository.NewFileStorage(*storageDir)
+		if err != nil {
+			return fail(err)
+		}
+		err = snapshot.RestoreStorage(&storage)
+	} else {
+		err = snapshot.RestoreDir(*dir)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d flags and %d variables taken at %s\n",
+		len(snapshot.Configurations), len(snapshot.Variables), snapshot.CreatedAt.Format(time.RFC3339))
+	return 0
+}
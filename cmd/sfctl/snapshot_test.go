@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/simpleflags/evaluation"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportImportDiffRoundTrip(t *testing.T) {
+	dir := flagsDir(t)
+	bundle := filepath.Join(t.TempDir(), "bundle.json")
+	if code := runExport([]string{"-from", dir, "-o", bundle}); code != 0 {
+		t.Fatalf("export exited with %d", code)
+	}
+
+	restored := t.TempDir()
+	if code := runImport([]string{"-dir", restored, bundle}); code != 0 {
+		t.Fatalf("import into directory exited with %d", code)
+	}
+	storage := t.TempDir()
+	if code := runImport([]string{"-storage", storage, bundle}); code != 0 {
+		t.Fatalf("import into storage exited with %d", code)
+	}
+
+	for _, other := range []string{bundle, restored, storage} {
+		if code := runDiff([]string{dir, other}); code != 0 {
+			t.Fatalf("expected %s to equal the source, diff exited with %d", other, code)
+		}
+	}
+
+	writeItem(t, restored, "flags", "new_checkout", evaluation.Configuration{Identifier: "new_checkout"})
+	if code := runDiff([]string{"-json", dir, restored}); code != 1 {
+		t.Fatalf("expected added flag to be reported, diff exited with %d", code)
+	}
+}
+
+func TestImportUsage(t *testing.T) {
+	for _, args := range [][]string{{"bundle.json"}, {"-dir", "a", "-storage", "b", "bundle.json"}, {"-dir", "a"}} {
+		if code := runImport(args); code != 2 {
+			t.Fatalf("args %v: expected exit code 2, got %d", args, code)
+		}
+	}
+}
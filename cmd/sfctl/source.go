@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/connector/simple"
+	"github.com/simpleflags/golang-server-sdk/repository"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// source is location flags are read from:
+//   - http(s) url of the api, sdk key is required
+//   - directory in FileConnector layout with flags and variables subdirectories
+//   - directory written by repository.FileStorage
+//   - snapshot bundle file written by sfctl export
+type source struct {
+	location string
+	sdkKey   string
+}
+
+func (s source) String() string {
+	return s.location
+}
+
+// connector opens the source
+func (s source) connector() (connector.Connector, error) {
+	if strings.HasPrefix(s.location, "http://") || strings.HasPrefix(s.location, "https://") {
+		if s.sdkKey == "" {
+			return nil, errors.New("sdk key is required to read from api, use -sdk-key or SF_SDK_KEY")
+		}
+		return simple.NewHttpConnector(s.sdkKey, simple.WithBaseURL(s.location)), nil
+	}
+
+	info, err := os.Stat(s.location)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		snapshot, err := repository.ReadSnapshotFile(s.location)
+		if err != nil {
+			return nil, err
+		}
+		return connector.NewStaticConnector(snapshot.Configurations, snapshot.Variables), nil
+	}
+
+	if isDir(filepath.Join(s.location, "flags")) {
+		dir, err := filepath.Abs(s.location)
+		if err != nil {
+			return nil, err
+		}
+		// FileConnector reads from <path>/<sdkKey>
+		return connector.NewFileConnector(filepath.Base(dir), filepath.Dir(dir))
+	}
+
+	storage, err := repository.NewFileStorage(s.location)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := repository.ExportStorage(&storage)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshot.Configurations) == 0 && len(snapshot.Variables) == 0 {
+		return nil, fmt.Errorf("no flags or variables found in %s", s.location)
+	}
+	return connector.NewStaticConnector(snapshot.Configurations, snapshot.Variables), nil
+}
+
+// load reads all flags and variables from the source
+func (s source) load(ctx context.Context) (evaluation.Configurations, []evaluation.Variable, error) {
+	conn, err := s.connector()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	configurations, err := conn.Configurations(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading flags from %s: %w", s, err)
+	}
+	variables, err := conn.Variables(ctx)
+	if err != nil && !isNotExist(err) {
+		return nil, nil, fmt.Errorf("loading variables from %s: %w", s, err)
+	}
+	return configurations, variables, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
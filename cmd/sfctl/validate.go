@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// problem found in a flag or variable file
+type problem struct {
+	path    string
+	message string
+}
+
+func (p problem) String() string {
+	return p.path + ": " + p.message
+}
+
+// validator collects problems of a directory in FileConnector layout
+type validator struct {
+	problems []problem
+	// warnings are printed and fail the validation only in strict mode
+	warnings []problem
+	// attributes are target attribute names rules may use, nil when unknown
+	attributes map[string]bool
+}
+
+func (v *validator) report(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, problem{path: path, message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warn(path, format string, args ...interface{}) {
+	v.warnings = append(v.warnings, problem{path: path, message: "warning: " + fmt.Sprintf(format, args...)})
+}
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	attributes := fs.String("attributes", "", "comma separated target attributes rules may use, other names must be variables")
+	strict := fs.Bool("strict", false, "fail on warnings too, recommended for CI")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sfctl validate [flags] <dir>")
+		fmt.Fprintln(fs.Output(), "dir holds flags and variables subdirectories as read by FileConnector")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	dir := fs.Arg(0)
+	if !isDir(filepath.Join(dir, "flags")) {
+		return fail(fmt.Errorf("%s has no flags directory", dir))
+	}
+
+	v := &validator{}
+	if *attributes != "" {
+		v.attributes = make(map[string]bool)
+		for _, name := range strings.Split(*attributes, ",") {
+			v.attributes[strings.TrimSpace(name)] = true
+		}
+	}
+	variables := v.variables(filepath.Join(dir, "variables"))
+	configurations := v.configurations(filepath.Join(dir, "flags"))
+	v.references(configurations, variables)
+
+	for _, problems := range [][]problem{v.warnings, v.problems} {
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problems[i].path < problems[j].path
+		})
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+	}
+	if len(v.problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(v.problems))
+		return 1
+	}
+	if *strict && len(v.warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "%d warnings found in strict mode\n", len(v.warnings))
+		return 1
+	}
+	fmt.Printf("%d flags and %d variables are valid\n", len(configurations), len(variables))
+	return 0
+}
+
+// files returns json files of the directory, missing directory has no files
+func (v *validator) files(dir string) []string {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !isNotExist(err) {
+			v.report(dir, "%v", err)
+		}
+		return nil
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files
+}
+
+// decode parses the file and checks identifier against file name and earlier files
+func (v *validator) decode(path string, value interface{}, identifier func() string, seen map[string]string) bool {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		v.report(path, "%v", err)
+		return false
+	}
+	if err := json.Unmarshal(bytes, value); err != nil {
+		v.report(path, "invalid JSON: %v", err)
+		return false
+	}
+
+	id := identifier()
+	if id == "" {
+		v.report(path, "identifier is empty")
+		return false
+	}
+	if name := strings.TrimSuffix(filepath.Base(path), ".json"); name != id {
+		v.report(path, "identifier %q does not match file name %q", id, name)
+	}
+	if first, ok := seen[id]; ok {
+		v.report(path, "duplicate identifier %q, first defined in %s", id, first)
+		return false
+	}
+	seen[id] = path
+	return true
+}
+
+// variables parses variable files and returns paths keyed by identifier
+func (v *validator) variables(dir string) map[string]string {
+	seen := make(map[string]string)
+	for _, path := range v.files(dir) {
+		var variable evaluation.Variable
+		v.decode(path, &variable, func() string { return variable.Identifier }, seen)
+	}
+	return seen
+}
+
+// configurations parses flag files and returns them keyed by file path
+func (v *validator) configurations(dir string) map[string]evaluation.Configuration {
+	seen := make(map[string]string)
+	configurations := make(map[string]evaluation.Configuration)
+	for _, path := range v.files(dir) {
+		var config evaluation.Configuration
+		if v.decode(path, &config, func() string { return config.Identifier }, seen) {
+			configurations[path] = config
+		}
+	}
+	return configurations
+}
+
+// references compiles rule expressions and checks referenced names. A name which is
+// not a variable may be a target attribute, so it is only a warning unless
+// attributes were given.
+func (v *validator) references(configurations map[string]evaluation.Configuration, variables map[string]string) {
+	for path, config := range configurations {
+		for i, rule := range config.Rules {
+			names, err := evaluation.Variables(rule.Expression)
+			if err != nil {
+				v.report(path, "rule %d: invalid expression %q: %v", i, rule.Expression, err)
+				continue
+			}
+			for _, name := range names {
+				if _, ok := variables[name]; ok {
+					continue
+				}
+				switch {
+				case v.attributes == nil:
+					v.warn(path, "rule %d: %q is not a variable, it must be a target attribute", i, name)
+				case !v.attributes[name]:
+					v.report(path, "rule %d: %q is neither a variable nor a target attribute", i, name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/simpleflags/evaluation"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeItem writes item as json file of the FileConnector layout
+func writeItem(t *testing.T, dir, sub, name string, item interface{}) {
+	t.Helper()
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, sub, name+".json"), data)
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// flagsDir creates directory with a flag using variable beta_users and attribute country
+func flagsDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeItem(t, dir, "flags", "dark_mode", evaluation.Configuration{
+		Identifier: "dark_mode",
+		Rules:      []evaluation.Rule{{Expression: `identifier in beta_users and country == "CZ"`}},
+	})
+	writeItem(t, dir, "variables", "beta_users", evaluation.Variable{Identifier: "beta_users"})
+	return dir
+}
+
+func TestValidateExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		setup func(t *testing.T, dir string)
+		code  int
+	}{
+		{name: "warnings only", code: 0},
+		{name: "strict fails on warnings", args: []string{"-strict"}, code: 1},
+		{name: "strict with attributes", args: []string{"-strict", "-attributes", "identifier,country"}, code: 0},
+		{name: "unknown attribute", args: []string{"-attributes", "identifier"}, code: 1},
+		{name: "invalid json", code: 1, setup: func(t *testing.T, dir string) {
+			writeFile(t, filepath.Join(dir, "flags", "broken.json"), []byte("{"))
+		}},
+		{name: "invalid expression", code: 1, setup: func(t *testing.T, dir string) {
+			writeItem(t, dir, "flags", "broken", evaluation.Configuration{
+				Identifier: "broken",
+				Rules:      []evaluation.Rule{{Expression: `country == "CZ`}},
+			})
+		}},
+		{name: "identifier does not match file", code: 1, setup: func(t *testing.T, dir string) {
+			writeItem(t, dir, "flags", "other", evaluation.Configuration{Identifier: "dark_mode"})
+		}},
+		{name: "missing flags directory", code: 1, setup: func(t *testing.T, dir string) {
+			if err := os.RemoveAll(filepath.Join(dir, "flags")); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := flagsDir(t)
+			if test.setup != nil {
+				test.setup(t, dir)
+			}
+			if code := runValidate(append(test.args, dir)); code != test.code {
+				t.Fatalf("expected exit code %d, got %d", test.code, code)
+			}
+		})
+	}
+}
+
+func TestValidateUsage(t *testing.T) {
+	for _, args := range [][]string{{}, {"a", "b"}, {"-unknown", "a"}} {
+		if code := runValidate(args); code != 2 {
+			t.Fatalf("args %v: expected exit code 2, got %d", args, code)
+		}
+	}
+}
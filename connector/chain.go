@@ -0,0 +1,145 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"sync"
+	"time"
+)
+
+// DefaultAttemptTimeout bounds a read from a source before the chain falls back to the next one
+const DefaultAttemptTimeout = time.Second * 10
+
+// ChainConnector reads from the first source that succeeds. Primary source is
+// always tried first, so the chain switches back as soon as primary recovers.
+// Variables are read from the source which served the last configurations,
+// so a pull never mixes data of several sources. Changes are streamed from
+// the primary source only.
+type ChainConnector struct {
+	sources []Connector
+	timeout time.Duration
+	mux     sync.RWMutex
+	current int
+	// pinned is set once configurations were served by some source
+	pinned bool
+}
+
+var _ Connector = &ChainConnector{}
+
+// Chain creates connector falling back from primary to fallbacks in given order,
+// for example http api, then file snapshot, then embedded defaults.
+// Sources get DefaultAttemptTimeout to respond.
+func Chain(primary Connector, fallbacks ...Connector) *ChainConnector {
+	return ChainWithTimeout(DefaultAttemptTimeout, primary, fallbacks...)
+}
+
+// ChainWithTimeout creates chain where reads from every source but the last one
+// are cut off after timeout, zero waits as long as the caller's ctx allows
+func ChainWithTimeout(timeout time.Duration, primary Connector, fallbacks ...Connector) *ChainConnector {
+	return &ChainConnector{
+		sources: append([]Connector{primary}, fallbacks...),
+		timeout: timeout,
+	}
+}
+
+// Source returns index of the source which served the last successful read,
+// zero is the primary
+func (c *ChainConnector) Source() int {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return c.current
+}
+
+// Current returns the source which served the last successful read
+func (c *ChainConnector) Current() Connector {
+	return c.sources[c.Source()]
+}
+
+// Primary reports if the last successful read was served by the primary source
+func (c *ChainConnector) Primary() bool {
+	return c.Source() == 0
+}
+
+func (c *ChainConnector) served(index int) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.pinned = true
+	if c.current != index {
+		log.Infof("chain connector switched from source %d (%T) to source %d (%T)",
+			c.current, c.sources[c.current], index, c.sources[index])
+		c.current = index
+	}
+}
+
+// attempt returns ctx for a read from the source, the last source is not cut off
+// because there is nothing to fall back to
+func (c *ChainConnector) attempt(ctx context.Context, index int) (context.Context, context.CancelFunc) {
+	if c.timeout <= 0 || index == len(c.sources)-1 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *ChainConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	var lastErr error
+	for i, source := range c.sources {
+		attemptCtx, cancel := c.attempt(ctx, i)
+		configurations, err := source.Configurations(attemptCtx, identifiers...)
+		cancel()
+		if err == nil {
+			c.served(i)
+			return configurations, nil
+		}
+		log.Errorf("error loading flags from source %d (%T): %v", i, source, err)
+		lastErr = err
+	}
+	return evaluation.Configurations{}, fmt.Errorf("all sources failed: %w", lastErr)
+}
+
+// Variables reads from the source which served the last configurations. Before
+// any configurations were read it falls back like Configurations.
+func (c *ChainConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	c.mux.RLock()
+	current, pinned := c.current, c.pinned
+	c.mux.RUnlock()
+
+	if pinned {
+		source := c.sources[current]
+		attemptCtx, cancel := c.attempt(ctx, current)
+		defer cancel()
+		variables, err := source.Variables(attemptCtx, identifiers...)
+		if err != nil {
+			return []evaluation.Variable{}, fmt.Errorf("source %d (%T): %w", current, source, err)
+		}
+		return variables, nil
+	}
+
+	var lastErr error
+	for i, source := range c.sources {
+		attemptCtx, cancel := c.attempt(ctx, i)
+		variables, err := source.Variables(attemptCtx, identifiers...)
+		cancel()
+		if err == nil {
+			return variables, nil
+		}
+		log.Errorf("error loading variables from source %d (%T): %v", i, source, err)
+		lastErr = err
+	}
+	return []evaluation.Variable{}, fmt.Errorf("all sources failed: %w", lastErr)
+}
+
+func (c *ChainConnector) Stream(ctx context.Context, updater Updater) error {
+	return c.sources[0].Stream(ctx, updater)
+}
+
+func (c *ChainConnector) Close() error {
+	var firstErr error
+	for _, source := range c.sources {
+		if err := source.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
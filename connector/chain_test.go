@@ -0,0 +1,135 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubConnector serves fixed data, fails or hangs on demand
+type stubConnector struct {
+	mux            sync.Mutex
+	configurations evaluation.Configurations
+	variables      []evaluation.Variable
+	err            error
+	// hang blocks reads until ctx is done
+	hang bool
+	// streaming sources return streamErr from Stream, others don't support it
+	streaming bool
+	streamErr error
+	streamCtx context.Context
+}
+
+func (s *stubConnector) fail(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.err = err
+}
+
+func (s *stubConnector) read(ctx context.Context) error {
+	s.mux.Lock()
+	err, hang := s.err, s.hang
+	s.mux.Unlock()
+	if hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+func (s *stubConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	if err := s.read(ctx); err != nil {
+		return evaluation.Configurations{}, err
+	}
+	return s.configurations, nil
+}
+
+func (s *stubConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	if err := s.read(ctx); err != nil {
+		return []evaluation.Variable{}, err
+	}
+	return s.variables, nil
+}
+
+func (s *stubConnector) Stream(ctx context.Context, updater Updater) error {
+	if !s.streaming {
+		return ErrStreamNotSupported
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.streamCtx = ctx
+	return s.streamErr
+}
+
+func (s *stubConnector) Close() error {
+	return nil
+}
+
+func TestChainPinsSourceForVariables(t *testing.T) {
+	primary := &stubConnector{
+		configurations: evaluation.Configurations{{Identifier: "primary"}},
+		variables:      []evaluation.Variable{{Identifier: "primary"}},
+		err:            errors.New("down"),
+	}
+	fallback := &stubConnector{
+		configurations: evaluation.Configurations{{Identifier: "fallback"}},
+		variables:      []evaluation.Variable{{Identifier: "fallback"}},
+	}
+	chain := Chain(primary, fallback)
+	ctx := context.Background()
+
+	configurations, err := chain.Configurations(ctx)
+	if err != nil || configurations[0].Identifier != "fallback" {
+		t.Fatalf("expected fallback configurations, got %+v %v", configurations, err)
+	}
+	if chain.Primary() || chain.Current() != fallback {
+		t.Fatalf("expected fallback source, got %d", chain.Source())
+	}
+
+	// primary recovers in the middle of the pull
+	primary.fail(nil)
+	variables, err := chain.Variables(ctx)
+	if err != nil || variables[0].Identifier != "fallback" {
+		t.Fatalf("expected variables of the pinned source, got %+v %v", variables, err)
+	}
+
+	// the next pull switches back
+	if configurations, _ := chain.Configurations(ctx); configurations[0].Identifier != "primary" {
+		t.Fatalf("expected primary configurations, got %+v", configurations)
+	}
+	if variables, _ := chain.Variables(ctx); variables[0].Identifier != "primary" {
+		t.Fatalf("expected primary variables, got %+v", variables)
+	}
+}
+
+func TestChainPinnedSourceFailure(t *testing.T) {
+	primary := &stubConnector{configurations: evaluation.Configurations{{Identifier: "primary"}}}
+	fallback := &stubConnector{variables: []evaluation.Variable{{Identifier: "fallback"}}}
+	chain := Chain(primary, fallback)
+
+	if _, err := chain.Configurations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	primary.fail(errors.New("down"))
+	if _, err := chain.Variables(context.Background()); err == nil {
+		t.Fatal("expected error instead of variables from another source")
+	}
+}
+
+func TestChainBoundsAttempt(t *testing.T) {
+	primary := &stubConnector{hang: true}
+	fallback := &stubConnector{configurations: evaluation.Configurations{{Identifier: "fallback"}}}
+	chain := ChainWithTimeout(time.Millisecond*20, primary, fallback)
+
+	started := time.Now()
+	configurations, err := chain.Configurations(context.Background())
+	if err != nil || configurations[0].Identifier != "fallback" {
+		t.Fatalf("expected fallback configurations, got %+v %v", configurations, err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("primary attempt was not bounded, took %v", elapsed)
+	}
+}
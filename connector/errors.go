@@ -0,0 +1,12 @@
+package connector
+
+import "errors"
+
+var (
+	// ErrStreamNotSupported is returned by connectors which can't stream changes
+	ErrStreamNotSupported = errors.New("stream not supported")
+	// ErrNoSources is returned by composite connectors created without sources
+	ErrNoSources = errors.New("no sources")
+	// ErrEmptySecret is returned when webhook connector is created without secret
+	ErrEmptySecret = errors.New("webhook secret cannot be empty")
+)
@@ -3,7 +3,6 @@ package connector
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"github.com/simpleflags/evaluation"
 	"io/ioutil"
 	"os"
@@ -81,7 +80,7 @@ func (f FileConnector) Variables(ctx context.Context, identifiers ...string) ([]
 }
 
 func (f FileConnector) Stream(ctx context.Context, updater Updater) error {
-	return errors.New("not supported")
+	return ErrStreamNotSupported
 }
 
 func (f FileConnector) Close() error {
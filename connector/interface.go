@@ -6,6 +6,7 @@ import (
 )
 
 type Msg struct {
+	ID    []byte
 	Event []byte
 	Data  []byte
 }
@@ -14,6 +15,21 @@ type Updater interface {
 	OnDisconnect()
 	OnEvent(msg *Msg)
 	OnConnect()
+	// OnResync is called when events were missed and all data should be reloaded
+	OnResync()
+}
+
+// HeartbeatUpdater is implemented by updaters which track that the stream
+// is alive while no events arrive
+type HeartbeatUpdater interface {
+	OnHeartbeat()
+}
+
+// Heartbeat tells updater the stream is alive if it implements HeartbeatUpdater
+func Heartbeat(updater Updater) {
+	if h, ok := updater.(HeartbeatUpdater); ok {
+		h.OnHeartbeat()
+	}
 }
 
 type Connector interface {
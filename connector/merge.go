@@ -0,0 +1,341 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"sync"
+)
+
+// Precedence decides which source wins when several sources define the same identifier
+type Precedence int
+
+const (
+	// ByPriority earlier source always wins
+	ByPriority Precedence = iota
+	// ByVersion flag with higher version wins, on equal versions earlier source wins.
+	// Variables have no version, they are always resolved by priority.
+	ByVersion
+)
+
+// MergeConnector unions configurations and variables from several sources,
+// for example kill switches owned by platform team in files and product
+// flags managed on the server. Failing sources are skipped, so an outage
+// of one source doesn't hide flags of the others.
+type MergeConnector struct {
+	sources    []Connector
+	precedence Precedence
+	mux        sync.RWMutex
+	// owners maps flag and variable keys to index of the source serving them
+	owners map[string]int
+	// versions holds version of the served flag or variable for every key
+	versions map[string]int64
+	// streams holds connection state of every streaming source
+	streams      map[int]bool
+	cancelStream context.CancelFunc
+}
+
+var _ Connector = &MergeConnector{}
+
+// Merge creates connector where earlier sources take precedence over later ones
+func Merge(sources ...Connector) *MergeConnector {
+	return newMerge(ByPriority, sources)
+}
+
+// MergeByVersion creates connector where flag with higher version wins,
+// variables are resolved by priority
+func MergeByVersion(sources ...Connector) *MergeConnector {
+	return newMerge(ByVersion, sources)
+}
+
+func newMerge(precedence Precedence, sources []Connector) *MergeConnector {
+	return &MergeConnector{
+		sources:    sources,
+		precedence: precedence,
+		owners:     make(map[string]int),
+		versions:   make(map[string]int64),
+	}
+}
+
+// mergeItem is a flag or variable read from one of the sources
+type mergeItem struct {
+	key     string
+	version int64
+	source  int
+}
+
+// merge returns positions of items which win, in order of their first appearance.
+// Items owned by a failed source are kept by it until it is back.
+func (m *MergeConnector) merge(items []mergeItem, failed map[int]bool) []int {
+	winners := make([]int, 0, len(items))
+	index := make(map[string]int)
+	for i, item := range items {
+		pos, ok := index[item.key]
+		if !ok {
+			index[item.key] = len(winners)
+			winners = append(winners, i)
+			continue
+		}
+		if m.precedence == ByVersion && item.version > items[winners[pos]].version {
+			winners[pos] = i
+		}
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	merged := winners[:0]
+	for _, i := range winners {
+		item := items[i]
+		owner, owned := m.owners[item.key]
+		if owned && failed[owner] && !m.wins(item.source, item.version, owner, m.versions[item.key]) {
+			continue
+		}
+		m.owners[item.key] = item.source
+		m.versions[item.key] = item.version
+		merged = append(merged, i)
+	}
+	return merged
+}
+
+func (m *MergeConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	if len(m.sources) == 0 {
+		return evaluation.Configurations{}, ErrNoSources
+	}
+
+	all := make(evaluation.Configurations, 0)
+	items := make([]mergeItem, 0)
+	var lastErr error
+	failed := make(map[int]bool)
+	for i, source := range m.sources {
+		configurations, err := source.Configurations(ctx, identifiers...)
+		if err != nil {
+			log.Errorf("skipping flags of source %d (%T): %v", i, source, err)
+			lastErr = err
+			failed[i] = true
+			continue
+		}
+		for _, config := range configurations {
+			all = append(all, config)
+			items = append(items, mergeItem{key: flagKey(config.Identifier), version: config.Version, source: i})
+		}
+	}
+	if len(failed) == len(m.sources) {
+		return evaluation.Configurations{}, fmt.Errorf("all sources failed: %w", lastErr)
+	}
+
+	merged := make(evaluation.Configurations, 0, len(all))
+	for _, i := range m.merge(items, failed) {
+		merged = append(merged, all[i])
+	}
+	return merged, nil
+}
+
+func (m *MergeConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	if len(m.sources) == 0 {
+		return []evaluation.Variable{}, ErrNoSources
+	}
+
+	all := make([]evaluation.Variable, 0)
+	items := make([]mergeItem, 0)
+	var lastErr error
+	failed := make(map[int]bool)
+	for i, source := range m.sources {
+		variables, err := source.Variables(ctx, identifiers...)
+		if err != nil {
+			log.Errorf("skipping variables of source %d (%T): %v", i, source, err)
+			lastErr = err
+			failed[i] = true
+			continue
+		}
+		for _, variable := range variables {
+			all = append(all, variable)
+			// variables have no version, equal versions leave the decision to priority
+			items = append(items, mergeItem{key: variableKey(variable.Identifier), source: i})
+		}
+	}
+	if len(failed) == len(m.sources) {
+		return []evaluation.Variable{}, fmt.Errorf("all sources failed: %w", lastErr)
+	}
+
+	merged := make([]evaluation.Variable, 0, len(all))
+	for _, i := range m.merge(items, failed) {
+		merged = append(merged, all[i])
+	}
+	return merged, nil
+}
+
+// Stream starts streams of all sources which support it. Client is reported
+// connected only when all of them are connected, so polling keeps sources
+// with broken stream fresh. When a source fails to start, streams of the
+// others are stopped.
+func (m *MergeConnector) Stream(ctx context.Context, updater Updater) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	m.mux.Lock()
+	if m.cancelStream != nil {
+		m.cancelStream()
+	}
+	m.cancelStream = cancel
+	m.streams = make(map[int]bool, len(m.sources))
+	for i := range m.sources {
+		m.streams[i] = false
+	}
+	m.mux.Unlock()
+
+	for i, source := range m.sources {
+		err := source.Stream(streamCtx, &mergeUpdater{merge: m, source: i, updater: updater})
+		if errors.Is(err, ErrStreamNotSupported) {
+			m.mux.Lock()
+			delete(m.streams, i)
+			m.mux.Unlock()
+			continue
+		}
+		if err != nil {
+			cancel()
+			return fmt.Errorf("source %d (%T): %w", i, source, err)
+		}
+	}
+
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if len(m.streams) == 0 {
+		cancel()
+		return ErrStreamNotSupported
+	}
+	return nil
+}
+
+func (m *MergeConnector) Close() error {
+	m.mux.Lock()
+	if m.cancelStream != nil {
+		m.cancelStream()
+	}
+	m.mux.Unlock()
+
+	var firstErr error
+	for _, source := range m.sources {
+		if err := source.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// accept reports if event from the source should reach the repository
+func (m *MergeConnector) accept(source int, msg *Msg) (accepted bool, resync bool) {
+	key, version, deleted, ok := eventKey(msg)
+	if !ok {
+		return true, false
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	owner, owned := m.owners[key]
+	switch {
+	case deleted && owned && owner == source:
+		// another source may still define it
+		delete(m.owners, key)
+		delete(m.versions, key)
+		return true, len(m.sources) > 1
+	case deleted:
+		return !owned, false
+	case !owned || owner == source || m.wins(source, version, owner, m.versions[key]):
+		m.owners[key] = source
+		m.versions[key] = version
+		return true, false
+	default:
+		return false, false
+	}
+}
+
+// wins reports if item of the source replaces item served by the owner
+func (m *MergeConnector) wins(source int, version int64, owner int, ownerVersion int64) bool {
+	if m.precedence == ByVersion && version != ownerVersion {
+		return version > ownerVersion
+	}
+	return source < owner
+}
+
+// connected marks stream of the source and returns true when all streams are connected
+func (m *MergeConnector) connected(source int, connected bool) bool {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.streams[source] = connected
+	for _, ok := range m.streams {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// eventKey extracts owner key and version from the event, variables have no version
+func eventKey(msg *Msg) (key string, version int64, deleted bool, ok bool) {
+	var item struct {
+		Identifier string
+		Version    int64
+	}
+	switch string(msg.Event) {
+	case evaluation.CreateFlagEvent, evaluation.PatchFlagEvent:
+		if json.Unmarshal(msg.Data, &item) != nil {
+			return "", 0, false, false
+		}
+		return flagKey(item.Identifier), item.Version, false, true
+	case evaluation.DeleteFlagEvent:
+		return flagKey(string(msg.Data)), 0, true, true
+	case evaluation.CreateVariable, evaluation.PatchVariable:
+		if json.Unmarshal(msg.Data, &item) != nil {
+			return "", 0, false, false
+		}
+		return variableKey(item.Identifier), 0, false, true
+	case evaluation.DeleteVariable:
+		return variableKey(string(msg.Data)), 0, true, true
+	}
+	return "", 0, false, false
+}
+
+func flagKey(identifier string) string {
+	return "flag__" + identifier
+}
+
+func variableKey(identifier string) string {
+	return "variable__" + identifier
+}
+
+// mergeUpdater filters events of a single source before passing them on
+type mergeUpdater struct {
+	merge   *MergeConnector
+	source  int
+	updater Updater
+}
+
+func (u *mergeUpdater) OnConnect() {
+	if u.merge.connected(u.source, true) {
+		u.updater.OnConnect()
+	}
+}
+
+func (u *mergeUpdater) OnDisconnect() {
+	u.merge.connected(u.source, false)
+	u.updater.OnDisconnect()
+}
+
+func (u *mergeUpdater) OnEvent(msg *Msg) {
+	accepted, resync := u.merge.accept(u.source, msg)
+	if accepted {
+		u.updater.OnEvent(msg)
+	}
+	if resync {
+		u.updater.OnResync()
+	}
+}
+
+func (u *mergeUpdater) OnResync() {
+	u.updater.OnResync()
+}
+
+func (u *mergeUpdater) OnHeartbeat() {
+	Heartbeat(u.updater)
+}
@@ -0,0 +1,121 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"testing"
+)
+
+func identifiers(configurations evaluation.Configurations) map[string]int64 {
+	result := make(map[string]int64, len(configurations))
+	for _, config := range configurations {
+		result[config.Identifier] = config.Version
+	}
+	return result
+}
+
+func TestMergeSkipsFailedSource(t *testing.T) {
+	file := &stubConnector{configurations: evaluation.Configurations{{Identifier: "kill_switch"}}}
+	server := &stubConnector{err: errors.New("down")}
+	merge := Merge(file, server)
+
+	configurations, err := merge.Configurations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := identifiers(configurations)["kill_switch"]; !ok || len(configurations) != 1 {
+		t.Fatalf("expected flags of the working source, got %+v", configurations)
+	}
+
+	file.fail(errors.New("down"))
+	if _, err := merge.Configurations(context.Background()); err == nil {
+		t.Fatal("expected error when all sources fail")
+	}
+}
+
+func TestMergeFailedOwnerKeepsItems(t *testing.T) {
+	file := &stubConnector{configurations: evaluation.Configurations{{Identifier: "dark_mode", Version: 1}}}
+	server := &stubConnector{configurations: evaluation.Configurations{{Identifier: "dark_mode", Version: 2}}}
+	merge := Merge(file, server)
+	ctx := context.Background()
+
+	if _, err := merge.Configurations(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// server copy must not replace the file copy while the file is unavailable
+	file.fail(errors.New("down"))
+	configurations, err := merge.Configurations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configurations) != 0 {
+		t.Fatalf("expected flag owned by failed source to be skipped, got %+v", configurations)
+	}
+}
+
+func TestMergeByVersion(t *testing.T) {
+	file := &stubConnector{configurations: evaluation.Configurations{{Identifier: "a", Version: 1}, {Identifier: "b", Version: 5}}}
+	server := &stubConnector{configurations: evaluation.Configurations{{Identifier: "a", Version: 3}, {Identifier: "b", Version: 5}}}
+	merge := MergeByVersion(file, server)
+
+	configurations, err := merge.Configurations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := identifiers(configurations); got["a"] != 3 || got["b"] != 5 || len(got) != 2 {
+		t.Fatalf("unexpected merge %+v", configurations)
+	}
+	if merge.owners[flagKey("a")] != 1 || merge.owners[flagKey("b")] != 0 {
+		t.Fatalf("unexpected owners %v", merge.owners)
+	}
+
+	// stream events with lower version from another source are dropped
+	old := &Msg{Event: []byte(evaluation.PatchFlagEvent), Data: []byte(`{"Identifier":"a","Version":2}`)}
+	if accepted, _ := merge.accept(0, old); accepted {
+		t.Fatal("expected older event to be dropped")
+	}
+	newer := &Msg{Event: []byte(evaluation.PatchFlagEvent), Data: []byte(`{"Identifier":"a","Version":4}`)}
+	if accepted, _ := merge.accept(0, newer); !accepted || merge.owners[flagKey("a")] != 0 {
+		t.Fatal("expected newer event to be accepted")
+	}
+}
+
+func TestMergeByVersionResolvesVariablesByPriority(t *testing.T) {
+	file := &stubConnector{variables: []evaluation.Variable{{Identifier: "beta_users"}}}
+	server := &stubConnector{variables: []evaluation.Variable{{Identifier: "beta_users"}, {Identifier: "regions"}}}
+	merge := MergeByVersion(file, server)
+
+	variables, err := merge.Variables(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variables) != 2 {
+		t.Fatalf("expected 2 variables, got %+v", variables)
+	}
+	if merge.owners[variableKey("beta_users")] != 0 || merge.owners[variableKey("regions")] != 1 {
+		t.Fatalf("unexpected owners %v", merge.owners)
+	}
+
+	// version in the event does not take the variable over
+	patch := &Msg{Event: []byte(evaluation.PatchVariable), Data: []byte(`{"Identifier":"beta_users","Version":9}`)}
+	if accepted, _ := merge.accept(1, patch); accepted {
+		t.Fatal("expected variable event of the later source to be dropped")
+	}
+	if accepted, _ := merge.accept(0, patch); !accepted {
+		t.Fatal("expected variable event of the owner to be accepted")
+	}
+}
+
+func TestMergeStopsStreamsWhenSourceFails(t *testing.T) {
+	first := &stubConnector{streaming: true}
+	second := &stubConnector{streaming: true, streamErr: errors.New("refused")}
+	merge := Merge(first, second)
+
+	if err := merge.Stream(context.Background(), nil); err == nil {
+		t.Fatal("expected stream error")
+	}
+	if first.streamCtx == nil || first.streamCtx.Err() == nil {
+		t.Fatal("expected stream of the first source to be stopped")
+	}
+}
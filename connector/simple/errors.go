@@ -0,0 +1,8 @@
+package simple
+
+import "errors"
+
+var (
+	// ErrUnexpectedStatus is returned when api responds with status other than 200
+	ErrUnexpectedStatus = errors.New("unexpected response status")
+)
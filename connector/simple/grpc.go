@@ -1 +1,266 @@
 package simple
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/backoff"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/connector/simple/pb"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+)
+
+// grpcService is the full name of the api service, used in span names
+const grpcService = "simpleflags.v1.SimpleFlags"
+
+// GrpcConnector loads flags and variables from SimpleFlags grpc api
+// and receives changes over server side stream
+type GrpcConnector struct {
+	apiKey       string
+	config       simpleFlagsConfig
+	conn         *grpc.ClientConn
+	client       pb.SimpleFlagsClient
+	tracker      *tracker
+	mux          sync.Mutex
+	streamCtx    context.Context
+	cancelStream context.CancelFunc
+}
+
+var _ connector.Connector = &GrpcConnector{}
+
+// NewGrpcConnector creates connector to grpc api at WithGrpcAddress.
+// Connection is established lazily on the first call.
+func NewGrpcConnector(apiKey string, options ...Option) (*GrpcConnector, error) {
+	config := newDefaultConfig()
+	for _, option := range options {
+		option(&config)
+	}
+
+	transport := credentials.NewTLS(config.tlsConfig())
+	if config.plaintext {
+		transport = insecure.NewCredentials()
+	}
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(transport),
+		grpc.WithPerRPCCredentials(apiKeyCredentials{
+			apiKey: apiKey,
+			config: config,
+		}),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                config.keepaliveTime,
+			Timeout:             config.keepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+	}
+	if config.userAgent != "" {
+		dialOptions = append(dialOptions, grpc.WithUserAgent(config.userAgent))
+	}
+	dialOptions = append(dialOptions, config.dialOptions...)
+
+	conn, err := grpc.Dial(config.grpcAddress, dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GrpcConnector{
+		apiKey:  apiKey,
+		config:  config,
+		conn:    conn,
+		client:  pb.NewSimpleFlagsClient(conn),
+		tracker: newTracker(),
+	}, nil
+}
+
+func (g *GrpcConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	ctx, span := g.startSpan(ctx, "Configurations")
+	configurations, err := g.configurations(ctx, identifiers...)
+	endSpan(span, err)
+	return configurations, err
+}
+
+func (g *GrpcConnector) configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	response, err := g.client.Configurations(ctx, &pb.ListRequest{Identifiers: identifiers})
+	if err != nil {
+		return evaluation.Configurations{}, err
+	}
+
+	configurations := make(evaluation.Configurations, 0, len(response.Configurations))
+	for _, data := range response.Configurations {
+		var config evaluation.Configuration
+		if err := json.Unmarshal(data, &config); err != nil {
+			return evaluation.Configurations{}, err
+		}
+		configurations = append(configurations, config)
+	}
+	g.seen(formatFlagKey, response.Configurations)
+	return configurations, nil
+}
+
+func (g *GrpcConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	ctx, span := g.startSpan(ctx, "Variables")
+	variables, err := g.variables(ctx, identifiers...)
+	endSpan(span, err)
+	return variables, err
+}
+
+func (g *GrpcConnector) variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	response, err := g.client.Variables(ctx, &pb.ListRequest{Identifiers: identifiers})
+	if err != nil {
+		return []evaluation.Variable{}, err
+	}
+
+	variables := make([]evaluation.Variable, 0, len(response.Variables))
+	for _, data := range response.Variables {
+		var variable evaluation.Variable
+		if err := json.Unmarshal(data, &variable); err != nil {
+			return []evaluation.Variable{}, err
+		}
+		variables = append(variables, variable)
+	}
+	g.seen(formatVariableKey, response.Variables)
+	return variables, nil
+}
+
+// Stream subscribes to changes until ctx is done, then it can be started again
+func (g *GrpcConnector) Stream(ctx context.Context, updater connector.Updater) error {
+	if err := backoff.Validate(g.config.streamBackoffMin, g.config.streamBackoffMax); err != nil {
+		return err
+	}
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if g.streamCtx != nil && g.streamCtx.Err() == nil {
+		log.Info("stream already started")
+		return nil
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	g.streamCtx, g.cancelStream = streamCtx, cancel
+
+	reconnect := backoff.New(g.config.streamBackoffMin, g.config.streamBackoffMax, backoff.WithContext(streamCtx))
+	go func() {
+		for {
+			err := g.subscribe(streamCtx, updater, reconnect)
+			if streamCtx.Err() != nil {
+				return
+			}
+			updater.OnDisconnect()
+			wait := reconnect.NextBackOff()
+			log.Errorf("Error connecting to the stream %v with reconnect timeout %f", err, wait.Seconds())
+			if backoff.Sleep(streamCtx, backoff.SystemClock{}, wait) != nil {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// subscribe opens the stream and passes events to updater until the stream breaks
+func (g *GrpcConnector) subscribe(ctx context.Context, updater connector.Updater, reconnect *backoff.Backoff) error {
+	stream, err := g.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	reconnect.Reset()
+	updater.OnConnect()
+	if g.tracker.connect() {
+		// nothing to resume from, events could be lost
+		updater.OnResync()
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		dispatch(g.tracker, updater, []byte(event.Id), []byte(event.Event), event.Data)
+	}
+}
+
+// open starts the stream and waits until server accepts it, span covers only the handshake
+func (g *GrpcConnector) open(ctx context.Context) (pb.SimpleFlags_StreamClient, error) {
+	ctx, span := g.startSpan(ctx, "Stream")
+	stream, err := g.client.Stream(ctx, &pb.StreamRequest{LastEventId: g.tracker.LastEventID()})
+	if err == nil {
+		// headers arrive once server accepted the stream, the api requires
+		// servers to send them right away and not with the first event
+		_, err = stream.Header()
+	}
+	endSpan(span, err)
+	return stream, err
+}
+
+// startSpan starts client span of the api method following OpenTelemetry rpc conventions
+func (g *GrpcConnector) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return g.config.tracer().Start(ctx, grpcService+"/"+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", grpcService),
+			attribute.String("rpc.method", method),
+		))
+}
+
+// endSpan records grpc status of the call and ends the span
+func endSpan(span trace.Span, err error) {
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(status.Code(err))))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// LastEventID returns id of the last event received from the stream
+func (g *GrpcConnector) LastEventID() string {
+	return g.tracker.LastEventID()
+}
+
+// seen records versions from api response items
+func (g *GrpcConnector) seen(key func(string) string, items [][]byte) {
+	versions := make([]versioned, 0, len(items))
+	for _, data := range items {
+		var v versioned
+		if err := json.Unmarshal(data, &v); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	g.tracker.seen(key, versions...)
+}
+
+func (g *GrpcConnector) Close() error {
+	g.mux.Lock()
+	if g.cancelStream != nil {
+		g.cancelStream()
+	}
+	g.mux.Unlock()
+	return g.conn.Close()
+}
+
+// apiKeyCredentials sends api key and extra headers as metadata with every call
+type apiKeyCredentials struct {
+	apiKey string
+	config simpleFlagsConfig
+}
+
+func (a apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	md := make(map[string]string, len(a.config.headers)+1)
+	for key, value := range a.config.headers {
+		md[strings.ToLower(key)] = value
+	}
+	md["api-key"] = a.apiKey
+	return md, nil
+}
+
+func (a apiKeyCredentials) RequireTransportSecurity() bool {
+	return !a.config.plaintext
+}
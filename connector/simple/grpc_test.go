@@ -0,0 +1,219 @@
+package simple
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/connector/simple/pb"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+// testServer serves fixed data and streams events pushed by the test
+type testServer struct {
+	pb.UnimplementedSimpleFlagsServer
+	events chan *pb.Event
+	// lastEventID holds resume ids requested by clients
+	lastEventID chan string
+}
+
+func authorized(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get("api-key"); len(keys) != 1 || keys[0] != "key" {
+		return status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	return nil
+}
+
+func (s *testServer) Configurations(ctx context.Context, req *pb.ListRequest) (*pb.ConfigurationsResponse, error) {
+	if err := authorized(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.ConfigurationsResponse{
+		Configurations: [][]byte{[]byte(`{"Identifier":"dark_mode","Version":3}`)},
+	}, nil
+}
+
+func (s *testServer) Variables(ctx context.Context, req *pb.ListRequest) (*pb.VariablesResponse, error) {
+	if err := authorized(ctx); err != nil {
+		return nil, err
+	}
+	return &pb.VariablesResponse{
+		Variables: [][]byte{[]byte(`{"Identifier":"beta_users"}`)},
+	}, nil
+}
+
+func (s *testServer) Stream(req *pb.StreamRequest, stream pb.SimpleFlags_StreamServer) error {
+	if err := authorized(stream.Context()); err != nil {
+		return err
+	}
+	s.lastEventID <- req.LastEventId
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-s.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newTestGrpcConnector(t *testing.T, apiKey string, options ...Option) (*GrpcConnector, *testServer) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	srv := &testServer{
+		events:      make(chan *pb.Event),
+		lastEventID: make(chan string, 10),
+	}
+	pb.RegisterSimpleFlagsServer(server, srv)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := NewGrpcConnector(apiKey, append([]Option{
+		WithGrpcAddress("bufnet"),
+		WithPlaintext(true),
+		WithStreamBackoff(time.Millisecond, time.Millisecond*10),
+		WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		})),
+	}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn, srv
+}
+
+func TestGrpcConnectorReadsData(t *testing.T) {
+	conn, _ := newTestGrpcConnector(t, "key")
+	ctx := context.Background()
+
+	configurations, err := conn.Configurations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configurations) != 1 || configurations[0].Identifier != "dark_mode" {
+		t.Fatalf("unexpected configurations %+v", configurations)
+	}
+
+	variables, err := conn.Variables(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variables) != 1 || variables[0].Identifier != "beta_users" {
+		t.Fatalf("unexpected variables %+v", variables)
+	}
+}
+
+func TestGrpcConnectorSendsApiKey(t *testing.T) {
+	conn, _ := newTestGrpcConnector(t, "wrong")
+	_, err := conn.Configurations(context.Background())
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected unauthenticated, got %v", err)
+	}
+}
+
+func TestGrpcConnectorStream(t *testing.T) {
+	conn, srv := newTestGrpcConnector(t, "key")
+	updater := newRecordingUpdater()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := conn.Stream(ctx, updater); err != nil {
+		t.Fatal(err)
+	}
+	// connected before any event arrives
+	eventually(t, func() bool { return updater.connects.Load() == 1 }, "quiet stream was not reported connected")
+
+	srv.events <- &pb.Event{Id: "7", Event: evaluation.DeleteFlagEvent, Data: []byte("dark_mode")}
+	srv.events <- &pb.Event{Id: "8", Event: PingEvent}
+	eventually(t, func() bool { return conn.LastEventID() == "7" }, "event was not received")
+	eventually(t, func() bool { return updater.heartbeats.Load() == 1 }, "ping was not reported as heartbeat")
+	if updater.events.Load() != 1 {
+		t.Fatalf("expected single event, got %d", updater.events.Load())
+	}
+	if id := <-srv.lastEventID; id != "" {
+		t.Fatalf("expected fresh stream, got last event id %q", id)
+	}
+
+	cancel()
+	time.Sleep(time.Millisecond * 50)
+	if updater.disconnects.Load() != 0 {
+		t.Fatalf("cancelled stream reported %d disconnects", updater.disconnects.Load())
+	}
+}
+
+func TestGrpcConnectorStreamResumes(t *testing.T) {
+	conn, srv := newTestGrpcConnector(t, "key")
+	updater := newRecordingUpdater()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := conn.Stream(ctx, updater); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.lastEventID
+	srv.events <- &pb.Event{Id: "7", Event: evaluation.DeleteFlagEvent, Data: []byte("dark_mode")}
+	eventually(t, func() bool { return conn.LastEventID() == "7" }, "event was not received")
+
+	// restart the stream, it resumes from the last event
+	cancel()
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	eventually(t, func() bool { return conn.Stream(ctx, updater) == nil && updater.connects.Load() == 2 },
+		"stream was not restarted")
+	if id := <-srv.lastEventID; id != "7" {
+		t.Fatalf("expected resume from 7, got %q", id)
+	}
+}
+
+func TestGrpcConnectorTracesCalls(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	conn, _ := newTestGrpcConnector(t, "key", WithTracerProvider(provider))
+
+	if _, err := conn.Configurations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "simpleflags.v1.SimpleFlags/Configurations" {
+		t.Fatalf("expected configurations span, got %+v", spans)
+	}
+	if spans[0].SpanKind != trace.SpanKindClient || spans[0].Status.Code != otelcodes.Unset {
+		t.Fatalf("unexpected span %+v", spans[0])
+	}
+
+	exporter.Reset()
+	denied, _ := newTestGrpcConnector(t, "other", WithTracerProvider(provider))
+	if _, err := denied.Variables(context.Background()); err == nil {
+		t.Fatal("expected unauthenticated error")
+	}
+	spans = exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != otelcodes.Error {
+		t.Fatalf("expected failed variables span, got %+v", spans)
+	}
+	for _, attr := range spans[0].Attributes {
+		if attr.Key == "rpc.grpc.status_code" && attr.Value.AsInt64() != int64(codes.Unauthenticated) {
+			t.Fatalf("unexpected status code %v", attr.Value.AsInt64())
+		}
+	}
+}
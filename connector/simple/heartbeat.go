@@ -0,0 +1,103 @@
+package simple
+
+import (
+	"context"
+	"github.com/simpleflags/golang-server-sdk/backoff"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"go.uber.org/atomic"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PingEvent is sent by the server to keep the stream alive,
+// it is passed to the updater as a heartbeat, not as an event
+const PingEvent = "ping"
+
+// heartbeat watches traffic on the stream and closes the connection
+// when nothing was received for longer than the read timeout.
+// Any bytes count as traffic, including comments and ping events.
+type heartbeat struct {
+	timeout  time.Duration
+	clock    backoff.Clock
+	next     http.RoundTripper
+	lastRead *atomic.Int64
+	mux      sync.Mutex
+	body     io.ReadCloser
+}
+
+func newHeartbeat(timeout time.Duration, clock backoff.Clock, next http.RoundTripper) *heartbeat {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &heartbeat{
+		timeout:  timeout,
+		clock:    clock,
+		next:     next,
+		lastRead: atomic.NewInt64(clock.Now().UnixNano()),
+	}
+}
+
+// RoundTrip implements http.RoundTripper and tracks reads on the response body
+func (h *heartbeat) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := h.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	h.touch()
+	body := &heartbeatBody{ReadCloser: resp.Body, heartbeat: h}
+	h.mux.Lock()
+	h.body = body
+	h.mux.Unlock()
+	resp.Body = body
+	return resp, nil
+}
+
+func (h *heartbeat) touch() {
+	h.lastRead.Store(h.clock.Now().UnixNano())
+}
+
+// idle returns how long the stream has been without traffic
+func (h *heartbeat) idle() time.Duration {
+	return h.clock.Now().Sub(time.Unix(0, h.lastRead.Load()))
+}
+
+// watch closes stale connections until ctx is done. Closing the body
+// makes the sse client report a disconnect and reconnect.
+func (h *heartbeat) watch(ctx context.Context) {
+	if h.timeout <= 0 {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.clock.After(h.timeout / 4):
+			if h.idle() < h.timeout {
+				continue
+			}
+			h.mux.Lock()
+			body := h.body
+			h.body = nil
+			h.mux.Unlock()
+			if body != nil {
+				log.Errorf("no traffic on the stream for %v, reconnecting", h.idle())
+				_ = body.Close()
+			}
+		}
+	}
+}
+
+type heartbeatBody struct {
+	io.ReadCloser
+	heartbeat *heartbeat
+}
+
+func (b *heartbeatBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.heartbeat.touch()
+	}
+	return n, err
+}
@@ -0,0 +1,103 @@
+package simple
+
+import (
+	"context"
+	"go.uber.org/atomic"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock fires waits only when the test advances time
+type manualClock struct {
+	mux     sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, manualWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// waiting returns number of waits which did not fire yet
+func (c *manualClock) waiting() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.waiters)
+}
+
+func (c *manualClock) advance(d time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+func withClock(clock *manualClock) Option {
+	return func(c *simpleFlagsConfig) {
+		c.clock = clock
+	}
+}
+
+func TestSilentStreamIsReconnected(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// no heartbeats
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	clock := &manualClock{now: time.Unix(1000, 0)}
+	conn := NewHttpConnector("key", WithStreamURL(server.URL), withClock(clock),
+		WithStreamBackoff(time.Millisecond, time.Millisecond*5), WithStreamReadTimeout(time.Second*10))
+	updater := newRecordingUpdater()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := conn.Stream(ctx, updater); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return updater.connects.Load() == 1 && clock.waiting() == 1 }, "stream did not connect")
+
+	// still within the timeout
+	clock.advance(time.Second * 9)
+	eventually(t, func() bool { return clock.waiting() == 1 }, "heartbeat stopped watching")
+	time.Sleep(time.Millisecond * 50)
+	if requests.Load() != 1 || updater.disconnects.Load() != 0 {
+		t.Fatalf("stream was reconnected before the timeout, %d requests", requests.Load())
+	}
+
+	// the next check is due a quarter of the timeout after the last one
+	clock.advance(time.Second * 3)
+	eventually(t, func() bool { return updater.disconnects.Load() == 1 }, "silent stream was not closed")
+	eventually(t, func() bool { return requests.Load() == 2 && updater.connects.Load() == 2 }, "silent stream was not reconnected")
+}
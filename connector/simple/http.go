@@ -2,79 +2,48 @@ package simple
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/r3labs/sse/v2"
 	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/backoff"
 	"github.com/simpleflags/golang-server-sdk/connector"
 	"github.com/simpleflags/golang-server-sdk/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
-type Option func(c *simpleFlagsConfig)
-
-type simpleFlagsConfig struct {
-	baseURL      string
-	eventsURL    string
-	streamURL    string
-	retryWaitMax time.Duration
-}
-
-func WithBaseURL(baseURL string) Option {
-	return func(c *simpleFlagsConfig) {
-		c.baseURL = baseURL
-	}
-}
-
-func WithEventsURL(eventsURL string) Option {
-	return func(c *simpleFlagsConfig) {
-		c.eventsURL = eventsURL
-	}
-}
-
-func WithStreamURL(streamURL string) Option {
-	return func(c *simpleFlagsConfig) {
-		c.streamURL = streamURL
-	}
-}
-
-func WithRetryWaitMax(max time.Duration) Option {
-	return func(c *simpleFlagsConfig) {
-		c.retryWaitMax = max
-	}
-}
-
 type HttpConnector struct {
 	apiKey          string
 	config          simpleFlagsConfig
 	baseApiClient   *http.Client
 	eventsApiClient *http.Client
-	stream          *sse.Client
+	mux             sync.Mutex
+	streamCtx       context.Context
 	cancelStream    context.CancelFunc
+	tracker         *tracker
 }
 
 func NewHttpConnector(apiKey string, options ...Option) *HttpConnector {
 
-	config := simpleFlagsConfig{
-		baseURL:      "http://localhost:1324/api",
-		eventsURL:    "http://localhost:1324/api",
-		streamURL:    "http://localhost:1325/api",
-		retryWaitMax: time.Second * 60,
-	}
-
+	config := newDefaultConfig()
 	for _, option := range options {
 		option(&config)
 	}
 
 	retryClient := retryablehttp.NewClient()
-	retryClient.RetryMax = 3
-	retryClient.RetryWaitMin = time.Second
-	retryClient.RetryMax = int(time.Second * config.retryWaitMax)
+	retryClient.HTTPClient = config.client()
+	retryClient.RetryMax = config.retryMax
+	retryClient.RetryWaitMin = config.retryWaitMin
+	retryClient.RetryWaitMax = config.retryWaitMax
 
 	baseApiClient := retryClient.StandardClient()
 	eventsApiClient := retryClient.StandardClient()
@@ -84,6 +53,7 @@ func NewHttpConnector(apiKey string, options ...Option) *HttpConnector {
 		config:          config,
 		baseApiClient:   baseApiClient,
 		eventsApiClient: eventsApiClient,
+		tracker:         newTracker(),
 	}
 }
 
@@ -98,10 +68,11 @@ func (f *HttpConnector) Configurations(ctx context.Context, identifiers ...strin
 		q.Set("identifiers", strIdentifiers)
 	}
 	address.RawQuery = q.Encode()
-	response, err := get(ctx, f.apiKey, f.baseApiClient, address.String())
+	response, err := f.get(ctx, f.baseApiClient, address.String())
 	if err != nil {
 		return []evaluation.Configuration{}, err
 	}
+	defer response.Body.Close()
 
 	bytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
@@ -109,7 +80,7 @@ func (f *HttpConnector) Configurations(ctx context.Context, identifiers ...strin
 	}
 
 	if response.StatusCode != 200 {
-		return []evaluation.Configuration{}, err
+		return []evaluation.Configuration{}, fmt.Errorf("%w: %s", ErrUnexpectedStatus, response.Status)
 	}
 
 	var configurations evaluation.Configurations
@@ -117,6 +88,7 @@ func (f *HttpConnector) Configurations(ctx context.Context, identifiers ...strin
 	if err != nil {
 		return []evaluation.Configuration{}, err
 	}
+	f.seen(formatFlagKey, bytes)
 	return configurations, nil
 }
 
@@ -131,10 +103,11 @@ func (f *HttpConnector) Variables(ctx context.Context, identifiers ...string) ([
 		q.Set("identifiers", strIdentifiers)
 	}
 	address.RawQuery = q.Encode()
-	response, err := get(ctx, f.apiKey, f.baseApiClient, address.String())
+	response, err := f.get(ctx, f.baseApiClient, address.String())
 	if err != nil {
 		return []evaluation.Variable{}, err
 	}
+	defer response.Body.Close()
 
 	bytes, err := ioutil.ReadAll(response.Body)
 	if err != nil {
@@ -142,7 +115,7 @@ func (f *HttpConnector) Variables(ctx context.Context, identifiers ...string) ([
 	}
 
 	if response.StatusCode != 200 {
-		return []evaluation.Variable{}, err
+		return []evaluation.Variable{}, fmt.Errorf("%w: %s", ErrUnexpectedStatus, response.Status)
 	}
 
 	var variables []evaluation.Variable
@@ -150,49 +123,52 @@ func (f *HttpConnector) Variables(ctx context.Context, identifiers ...string) ([
 	if err != nil {
 		return []evaluation.Variable{}, err
 	}
+	f.seen(formatVariableKey, bytes)
 	return variables, nil
 }
 
+// Stream subscribes to changes until ctx is done, then it can be started again
 func (f *HttpConnector) Stream(ctx context.Context, updater connector.Updater) error {
-	if f.stream != nil {
+	if err := backoff.Validate(f.config.streamBackoffMin, f.config.streamBackoffMax); err != nil {
+		return err
+	}
+	f.mux.Lock()
+	if f.streamCtx != nil && f.streamCtx.Err() == nil {
+		f.mux.Unlock()
 		log.Info("stream already started")
 		return nil
 	}
-	sseCtx, cancel := context.WithCancel(ctx)
-	f.cancelStream = cancel
-	f.stream = sse.NewClient(f.config.streamURL + "/stream")
-	f.stream.Headers["API-Key"] = f.apiKey
-	f.stream.Connection.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-
-	f.stream.OnConnect(func(c *sse.Client) {
-		updater.OnConnect()
-	})
-
-	f.stream.OnDisconnect(func(c *sse.Client) {
-		updater.OnDisconnect()
-	})
+	streamCtx, cancel := context.WithCancel(ctx)
+	f.streamCtx, f.cancelStream = streamCtx, cancel
+	f.mux.Unlock()
 
-	f.stream.ReconnectNotify = func(err error, duration time.Duration) {
-		log.Errorf("Error connecting to the stream %v with reconnect timeout %f", err.Error(), duration.Seconds())
-		if duration.Seconds() >= 60 {
-			updater.OnDisconnect()
+	// sse client retries until the backoff stops
+	reconnect := backoff.New(f.config.streamBackoffMin, f.config.streamBackoffMax, backoff.WithContext(streamCtx))
+	subscribe := f.subscribeWebSocket
+	if f.config.streamTransport != WebSocketTransport {
+		stream := f.newSSEClient(streamCtx, updater, reconnect)
+		subscribe = func(ctx context.Context, updater connector.Updater, reconnect *backoff.Backoff) error {
+			return f.subscribeSSE(ctx, stream, updater)
 		}
 	}
 
-	var errChan chan error
+	errChan := make(chan error, 1)
 	go func() {
-		errChan <- f.stream.SubscribeWithContext(sseCtx, "", func(msg *sse.Event) {
-			if msg == nil {
+		for {
+			err := subscribe(streamCtx, updater, reconnect)
+			if streamCtx.Err() != nil {
+				errChan <- err
+				return
+			}
+			// server closed the stream, subscribe again
+			updater.OnDisconnect()
+			wait := reconnect.NextBackOff()
+			log.Errorf("Error connecting to the stream %v with reconnect timeout %f", err, wait.Seconds())
+			if backoff.Sleep(streamCtx, backoff.SystemClock{}, wait) != nil {
+				errChan <- streamCtx.Err()
 				return
 			}
-			// Got some data!
-			updater.OnEvent(&connector.Msg{
-				Event: msg.Event,
-				Data:  msg.Data,
-			})
-		})
+		}
 	}()
 
 	select {
@@ -205,18 +181,102 @@ func (f *HttpConnector) Stream(ctx context.Context, updater connector.Updater) e
 	}
 }
 
+func (f *HttpConnector) newSSEClient(ctx context.Context, updater connector.Updater, reconnect *backoff.Backoff) *sse.Client {
+	stream := sse.NewClient(f.config.streamURL + "/stream")
+	base := f.config.client()
+	hb := newHeartbeat(f.config.streamReadTimeout, f.config.clock, base.Transport)
+	headers := http.Header{}
+	f.config.setHeaders(f.apiKey, headers)
+	stream.Connection = &http.Client{
+		Transport:     &headerTransport{header: headers, next: hb},
+		CheckRedirect: base.CheckRedirect,
+		Jar:           base.Jar,
+	}
+	go hb.watch(ctx)
+
+	stream.ReconnectStrategy = reconnect
+
+	stream.OnConnect(func(c *sse.Client) {
+		reconnect.Reset()
+		updater.OnConnect()
+		if f.tracker.connect() {
+			// nothing to resume from, events could be lost
+			updater.OnResync()
+		}
+	})
+
+	stream.OnDisconnect(func(c *sse.Client) {
+		// closing the stream breaks the read, it is not a drop
+		if ctx.Err() == nil {
+			updater.OnDisconnect()
+		}
+	})
+
+	// drops are reported by OnDisconnect, failed attempts only keep the client disconnected
+	stream.ReconnectNotify = func(err error, duration time.Duration) {
+		log.Errorf("Error connecting to the stream %v with reconnect timeout %f", err.Error(), duration.Seconds())
+	}
+	return stream
+}
+
+func (f *HttpConnector) subscribeSSE(ctx context.Context, stream *sse.Client, updater connector.Updater) error {
+	// resume from the last event we have seen
+	stream.EventID = f.tracker.LastEventID()
+	return stream.SubscribeWithContext(ctx, "", func(msg *sse.Event) {
+		if msg == nil {
+			return
+		}
+		// Got some data!
+		dispatch(f.tracker, updater, msg.ID, msg.Event, msg.Data)
+	})
+}
+
+// LastEventID returns id of the last event received from the stream
+func (f *HttpConnector) LastEventID() string {
+	return f.tracker.LastEventID()
+}
+
+// seen records versions from api response body
+func (f *HttpConnector) seen(key func(string) string, body []byte) {
+	var items []versioned
+	if err := json.Unmarshal(body, &items); err != nil {
+		return
+	}
+	f.tracker.seen(key, items...)
+}
+
 func (f *HttpConnector) Close() error {
-	if f.stream != nil {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	if f.cancelStream != nil {
 		f.cancelStream()
 	}
 	return nil
 }
 
-func get(ctx context.Context, apiKey string, client *http.Client, requestUrl string) (*http.Response, error) {
+func (f *HttpConnector) get(ctx context.Context, client *http.Client, requestUrl string) (*http.Response, error) {
 	req, err := http.NewRequest("GET", requestUrl, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("API-Key", apiKey)
-	return client.Do(req.WithContext(ctx))
+
+	ctx, span := f.config.tracer().Start(ctx, "GET "+req.URL.Path, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", requestUrl),
+		))
+	defer span.End()
+
+	f.config.setHeaders(f.apiKey, req.Header)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
 }
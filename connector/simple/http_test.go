@@ -0,0 +1,167 @@
+package simple
+
+import (
+	"context"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"go.uber.org/atomic"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// recordingUpdater counts stream notifications
+type recordingUpdater struct {
+	connects    *atomic.Int64
+	disconnects *atomic.Int64
+	events      *atomic.Int64
+	heartbeats  *atomic.Int64
+	resyncs     *atomic.Int64
+}
+
+func newRecordingUpdater() *recordingUpdater {
+	return &recordingUpdater{
+		connects:    atomic.NewInt64(0),
+		disconnects: atomic.NewInt64(0),
+		events:      atomic.NewInt64(0),
+		heartbeats:  atomic.NewInt64(0),
+		resyncs:     atomic.NewInt64(0),
+	}
+}
+
+func (u *recordingUpdater) OnConnect()                 { u.connects.Inc() }
+func (u *recordingUpdater) OnDisconnect()              { u.disconnects.Inc() }
+func (u *recordingUpdater) OnEvent(msg *connector.Msg) { u.events.Inc() }
+func (u *recordingUpdater) OnResync()                  { u.resyncs.Inc() }
+func (u *recordingUpdater) OnHeartbeat()               { u.heartbeats.Inc() }
+
+// eventually fails the test when condition is not met within two seconds
+func eventually(t *testing.T, condition func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 2)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestStreamStopsReconnectingWhenCancelled(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	transport := &http.Transport{}
+
+	before := runtime.NumGoroutine()
+	conn := NewHttpConnector("key", WithStreamURL(server.URL), WithTransport(transport),
+		WithStreamBackoff(time.Millisecond, time.Millisecond*5), WithStreamReadTimeout(0))
+	updater := newRecordingUpdater()
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := conn.Stream(ctx, updater); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return requests.Load() >= 3 }, "stream did not retry")
+
+	cancel()
+	time.Sleep(time.Millisecond * 50)
+	seen := requests.Load()
+	time.Sleep(time.Millisecond * 50)
+	if requests.Load() != seen {
+		t.Fatal("stream keeps reconnecting after cancel")
+	}
+
+	transport.CloseIdleConnections()
+	server.CloseClientConnections()
+	eventually(t, func() bool { return runtime.NumGoroutine() <= before }, "stream goroutines leaked")
+	if updater.connects.Load() != 0 || updater.disconnects.Load() != 0 {
+		t.Fatalf("unexpected notifications, %d connects and %d disconnects",
+			updater.connects.Load(), updater.disconnects.Load())
+	}
+}
+
+func TestClosedStreamIsNotReportedAsDrop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	conn := NewHttpConnector("key", WithStreamURL(server.URL),
+		WithStreamBackoff(time.Millisecond, time.Millisecond*5), WithStreamReadTimeout(0))
+	updater := newRecordingUpdater()
+	if err := conn.Stream(context.Background(), updater); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, func() bool { return updater.connects.Load() == 1 }, "stream did not connect")
+
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if updater.disconnects.Load() != 0 {
+		t.Fatalf("closed stream reported %d disconnects", updater.disconnects.Load())
+	}
+}
+
+func TestStreamRejectsInvalidBackoff(t *testing.T) {
+	conn := NewHttpConnector("key", WithStreamBackoff(0, 0))
+	if err := conn.Stream(context.Background(), newRecordingUpdater()); err == nil {
+		t.Fatal("expected error for invalid backoff")
+	}
+}
+
+func TestStreamResyncsOnGapAndResumes(t *testing.T) {
+	lastEventIDs := make(chan string, 10)
+	requests := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		if requests.Inc() == 1 {
+			// event 2 is missing, then the connection drops
+			_, _ = w.Write([]byte("id: 1\nevent: delete-flag\ndata: a\n\nid: 3\nevent: delete-flag\ndata: b\n\n"))
+			w.(http.Flusher).Flush()
+			return
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	conn := NewHttpConnector("key", WithStreamURL(server.URL),
+		WithStreamBackoff(time.Millisecond, time.Millisecond*5), WithStreamReadTimeout(0))
+	updater := newRecordingUpdater()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := conn.Stream(ctx, updater); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, func() bool { return updater.events.Load() == 2 }, "events were not received")
+	eventually(t, func() bool { return updater.resyncs.Load() == 1 }, "gap did not trigger resync")
+	if id := <-lastEventIDs; id != "" {
+		t.Fatalf("expected fresh first connection, got last event id %q", id)
+	}
+	select {
+	case id := <-lastEventIDs:
+		if id != "3" {
+			t.Fatalf("expected reconnect to resume from 3, got %q", id)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("stream did not reconnect")
+	}
+	// resumed stream has nothing to resync
+	time.Sleep(time.Millisecond * 50)
+	if updater.resyncs.Load() != 1 {
+		t.Fatalf("expected single resync, got %d", updater.resyncs.Load())
+	}
+}
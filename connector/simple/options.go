@@ -0,0 +1,316 @@
+package simple
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/simpleflags/golang-server-sdk/backoff"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type Option func(c *simpleFlagsConfig)
+
+type simpleFlagsConfig struct {
+	baseURL            string
+	eventsURL          string
+	streamURL          string
+	retryMax           int
+	retryWaitMin       time.Duration
+	retryWaitMax       time.Duration
+	httpClient         *http.Client
+	transport          http.RoundTripper
+	rootCAs            *x509.CertPool
+	certificates       []tls.Certificate
+	insecureSkipVerify bool
+	proxy              *url.URL
+	headers            map[string]string
+	userAgent          string
+	streamReadTimeout  time.Duration
+	streamBackoffMin   time.Duration
+	streamBackoffMax   time.Duration
+	grpcAddress        string
+	plaintext          bool
+	keepaliveTime      time.Duration
+	keepaliveTimeout   time.Duration
+	dialOptions        []grpc.DialOption
+	streamTransport    StreamTransport
+	tracerProvider     trace.TracerProvider
+	// clock drives stream read timeout, tests replace it
+	clock backoff.Clock
+}
+
+func WithBaseURL(baseURL string) Option {
+	return func(c *simpleFlagsConfig) {
+		c.baseURL = baseURL
+	}
+}
+
+func WithEventsURL(eventsURL string) Option {
+	return func(c *simpleFlagsConfig) {
+		c.eventsURL = eventsURL
+	}
+}
+
+func WithStreamURL(streamURL string) Option {
+	return func(c *simpleFlagsConfig) {
+		c.streamURL = streamURL
+	}
+}
+
+// WithRetryMax sets how many times a failed REST request is retried
+func WithRetryMax(max int) Option {
+	return func(c *simpleFlagsConfig) {
+		c.retryMax = max
+	}
+}
+
+// WithRetryWaitMin sets the minimum time to wait between REST retries
+func WithRetryWaitMin(min time.Duration) Option {
+	return func(c *simpleFlagsConfig) {
+		c.retryWaitMin = min
+	}
+}
+
+// WithRetryWaitMax sets the maximum time to wait between REST retries
+func WithRetryWaitMax(max time.Duration) Option {
+	return func(c *simpleFlagsConfig) {
+		c.retryWaitMax = max
+	}
+}
+
+// WithHTTPClient sets the http client used for REST calls and the stream.
+// The client must not have a Timeout set, otherwise the stream is cut off
+// after it expires. TLS, proxy and transport options are ignored when
+// a client is provided.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *simpleFlagsConfig) {
+		c.httpClient = client
+	}
+}
+
+// WithTransport sets the round tripper used for REST calls and the stream.
+// TLS and proxy options are ignored when a transport is provided, except
+// for the websocket stream which cannot use a round tripper.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *simpleFlagsConfig) {
+		c.transport = transport
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify the server
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(c *simpleFlagsConfig) {
+		c.rootCAs = pool
+	}
+}
+
+// WithClientCertificates sets certificates presented to the server for mutual TLS
+func WithClientCertificates(certificates ...tls.Certificate) Option {
+	return func(c *simpleFlagsConfig) {
+		c.certificates = certificates
+	}
+}
+
+// WithInsecureSkipVerify turns off server certificate verification.
+// It should only be used for local development.
+func WithInsecureSkipVerify(val bool) Option {
+	return func(c *simpleFlagsConfig) {
+		c.insecureSkipVerify = val
+	}
+}
+
+// WithProxy routes all requests through the HTTP proxy at proxyURL
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *simpleFlagsConfig) {
+		c.proxy = proxyURL
+	}
+}
+
+// WithHeader adds an extra header sent with every request
+func WithHeader(key, value string) Option {
+	return func(c *simpleFlagsConfig) {
+		c.headers[key] = value
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *simpleFlagsConfig) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithStreamReadTimeout sets how long the stream may stay silent before
+// the connection is considered stale and reopened. Server heartbeats
+// must arrive more often than this. Zero turns the check off.
+func WithStreamReadTimeout(timeout time.Duration) Option {
+	return func(c *simpleFlagsConfig) {
+		c.streamReadTimeout = timeout
+	}
+}
+
+// WithStreamTransport selects how HttpConnector receives change events,
+// SSETransport or WebSocketTransport
+func WithStreamTransport(transport StreamTransport) Option {
+	return func(c *simpleFlagsConfig) {
+		c.streamTransport = transport
+	}
+}
+
+// WithStreamBackoff sets exponential backoff used to reconnect the stream,
+// min must be positive and not greater than max
+func WithStreamBackoff(min, max time.Duration) Option {
+	return func(c *simpleFlagsConfig) {
+		c.streamBackoffMin = min
+		c.streamBackoffMax = max
+	}
+}
+
+// WithGrpcAddress sets host:port of the grpc api used by GrpcConnector
+func WithGrpcAddress(address string) Option {
+	return func(c *simpleFlagsConfig) {
+		c.grpcAddress = address
+	}
+}
+
+// WithPlaintext turns off TLS for GrpcConnector.
+// It should only be used for local development.
+func WithPlaintext(val bool) Option {
+	return func(c *simpleFlagsConfig) {
+		c.plaintext = val
+	}
+}
+
+// WithKeepalive sets how often GrpcConnector pings the server
+// and how long it waits for the ping ack before closing the connection.
+// Servers close connections pinged more often than their enforcement policy
+// allows, every 5 minutes with grpc-go defaults.
+func WithKeepalive(interval, timeout time.Duration) Option {
+	return func(c *simpleFlagsConfig) {
+		c.keepaliveTime = interval
+		c.keepaliveTimeout = timeout
+	}
+}
+
+// WithDialOptions adds grpc dial options used by GrpcConnector,
+// bufconn dialer in tests for example
+func WithDialOptions(options ...grpc.DialOption) Option {
+	return func(c *simpleFlagsConfig) {
+		c.dialOptions = append(c.dialOptions, options...)
+	}
+}
+
+// WithTracerProvider sets provider of spans for REST and gRPC calls, default is the global provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *simpleFlagsConfig) {
+		c.tracerProvider = provider
+	}
+}
+
+// instrumentationName is name of the tracer used by the SDK
+const instrumentationName = "github.com/simpleflags/golang-server-sdk"
+
+// tracer returns tracer of the configured or global provider
+func (c simpleFlagsConfig) tracer() trace.Tracer {
+	provider := c.tracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+func newDefaultConfig() simpleFlagsConfig {
+	return simpleFlagsConfig{
+		baseURL:          "http://localhost:1324/api",
+		eventsURL:        "http://localhost:1324/api",
+		streamURL:        "http://localhost:1325/api",
+		retryMax:         3,
+		retryWaitMin:     time.Second,
+		retryWaitMax:     time.Second * 60,
+		headers:          make(map[string]string),
+		streamBackoffMin: time.Second,
+		streamBackoffMax: time.Minute,
+		streamTransport:  SSETransport,
+		grpcAddress:      "localhost:1326",
+		keepaliveTime:    time.Minute * 5,
+		keepaliveTimeout: time.Second * 10,
+		// server sends heartbeat every 30 seconds
+		streamReadTimeout: time.Second * 90,
+		clock:             backoff.SystemClock{},
+	}
+}
+
+// tlsConfig returns TLS configuration built from options
+func (c simpleFlagsConfig) tlsConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:            c.rootCAs,
+		Certificates:       c.certificates,
+		InsecureSkipVerify: c.insecureSkipVerify, //nolint:gosec
+		MinVersion:         tls.VersionTLS12,
+	}
+}
+
+// client returns http client built from transport options
+func (c simpleFlagsConfig) client() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+	if c.transport != nil {
+		return &http.Client{Transport: c.transport}
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: c.proxyFunc(),
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			TLSClientConfig:       c.tlsConfig(),
+		},
+	}
+}
+
+// proxyFunc returns proxy set by WithProxy, or proxy from environment
+func (c simpleFlagsConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	if c.proxy != nil {
+		return http.ProxyURL(c.proxy)
+	}
+	return http.ProxyFromEnvironment
+}
+
+// headerTransport adds headers with all their values to every request,
+// the sse client can only set a single value per header
+type headerTransport struct {
+	header http.Header
+	next   http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// setHeaders adds api key and configured extra headers to the request
+func (c simpleFlagsConfig) setHeaders(apiKey string, header http.Header) {
+	for key, value := range c.headers {
+		header.Set(key, value)
+	}
+	if c.userAgent != "" {
+		header.Set("User-Agent", c.userAgent)
+	}
+	header.Set("API-Key", apiKey)
+}
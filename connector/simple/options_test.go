@@ -0,0 +1,136 @@
+package simple
+
+import (
+	"context"
+	"crypto/x509"
+	"go.uber.org/atomic"
+	"io/ioutil"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// emptyAPI serves empty flags and variables and counts requests
+func emptyAPI(requests *atomic.Int64, check func(r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		if check != nil {
+			check(r)
+		}
+		_, _ = w.Write([]byte("[]"))
+	}
+}
+
+func TestTLSVerificationIsOnByDefault(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	server := httptest.NewTLSServer(emptyAPI(requests, nil))
+	server.Config.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+	defer server.Close()
+
+	if newDefaultConfig().tlsConfig().InsecureSkipVerify {
+		t.Fatal("expected certificate verification by default")
+	}
+	conn := NewHttpConnector("key", WithBaseURL(server.URL), WithRetryMax(0))
+	if _, err := conn.Configurations(context.Background()); err == nil {
+		t.Fatal("expected untrusted certificate to be rejected")
+	}
+	if requests.Load() != 0 {
+		t.Fatalf("request reached server with untrusted certificate")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	conn = NewHttpConnector("key", WithBaseURL(server.URL), WithRootCAs(pool))
+	if _, err := conn.Configurations(context.Background()); err != nil {
+		t.Fatalf("expected certificate signed by root CA to be trusted: %v", err)
+	}
+}
+
+func TestProxyAndHeadersReachTransport(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	hosts := make(chan string, 2)
+	headers := make(chan http.Header, 2)
+	proxy := httptest.NewServer(emptyAPI(requests, func(r *http.Request) {
+		hosts <- r.Host
+		headers <- r.Header
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := NewHttpConnector("key", WithBaseURL("http://flags.example/api"), WithProxy(proxyURL),
+		WithHeader("X-Tenant", "acme"), WithUserAgent("tests/1.0"))
+	if _, err := conn.Configurations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Variables(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected both requests to go through the proxy, got %d", requests.Load())
+	}
+	for i := 0; i < 2; i++ {
+		if host := <-hosts; host != "flags.example" {
+			t.Fatalf("expected proxied request for flags.example, got %q", host)
+		}
+		header := <-headers
+		if header.Get("X-Tenant") != "acme" || header.Get("User-Agent") != "tests/1.0" || header.Get("API-Key") != "key" {
+			t.Fatalf("configured headers were not sent: %v", header)
+		}
+	}
+}
+
+func TestStreamSendsHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case headers <- r.Header:
+		default:
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	conn := NewHttpConnector("key", WithStreamURL(server.URL), WithHeader("X-Tenant", "acme"),
+		WithStreamReadTimeout(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := conn.Stream(ctx, newRecordingUpdater()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case header := <-headers:
+		if header.Get("X-Tenant") != "acme" || header.Get("API-Key") != "key" {
+			t.Fatalf("configured headers were not sent on the stream: %v", header)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("stream did not connect")
+	}
+}
+
+func TestRetryMax(t *testing.T) {
+	requests := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	conn := NewHttpConnector("key", WithBaseURL(server.URL), WithRetryMax(2),
+		WithRetryWaitMin(time.Millisecond), WithRetryWaitMax(time.Millisecond))
+	if _, err := conn.Configurations(context.Background()); err == nil {
+		t.Fatal("expected error after retries")
+	}
+	if requests.Load() != 3 {
+		t.Fatalf("expected first attempt and 2 retries, got %d requests", requests.Load())
+	}
+}
@@ -0,0 +1,215 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.2
+// source: simpleflags.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SimpleFlagsClient is the client API for SimpleFlags service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SimpleFlagsClient interface {
+	// Configurations returns flag configurations, all of them when no identifiers are given
+	Configurations(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ConfigurationsResponse, error)
+	// Variables returns variables, all of them when no identifiers are given
+	Variables(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*VariablesResponse, error)
+	// Stream sends change events until the client goes away. Server must send
+	// headers as soon as it accepts the stream, SendHeader in grpc-go, because
+	// clients report the stream connected when headers arrive.
+	Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (SimpleFlags_StreamClient, error)
+}
+
+type simpleFlagsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSimpleFlagsClient(cc grpc.ClientConnInterface) SimpleFlagsClient {
+	return &simpleFlagsClient{cc}
+}
+
+func (c *simpleFlagsClient) Configurations(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ConfigurationsResponse, error) {
+	out := new(ConfigurationsResponse)
+	err := c.cc.Invoke(ctx, "/simpleflags.v1.SimpleFlags/Configurations", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleFlagsClient) Variables(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*VariablesResponse, error) {
+	out := new(VariablesResponse)
+	err := c.cc.Invoke(ctx, "/simpleflags.v1.SimpleFlags/Variables", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *simpleFlagsClient) Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (SimpleFlags_StreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SimpleFlags_ServiceDesc.Streams[0], "/simpleflags.v1.SimpleFlags/Stream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &simpleFlagsStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SimpleFlags_StreamClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type simpleFlagsStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *simpleFlagsStreamClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SimpleFlagsServer is the server API for SimpleFlags service.
+// All implementations must embed UnimplementedSimpleFlagsServer
+// for forward compatibility
+type SimpleFlagsServer interface {
+	// Configurations returns flag configurations, all of them when no identifiers are given
+	Configurations(context.Context, *ListRequest) (*ConfigurationsResponse, error)
+	// Variables returns variables, all of them when no identifiers are given
+	Variables(context.Context, *ListRequest) (*VariablesResponse, error)
+	// Stream sends change events until the client goes away. Server must send
+	// headers as soon as it accepts the stream, SendHeader in grpc-go, because
+	// clients report the stream connected when headers arrive.
+	Stream(*StreamRequest, SimpleFlags_StreamServer) error
+	mustEmbedUnimplementedSimpleFlagsServer()
+}
+
+// UnimplementedSimpleFlagsServer must be embedded to have forward compatible implementations.
+type UnimplementedSimpleFlagsServer struct {
+}
+
+func (UnimplementedSimpleFlagsServer) Configurations(context.Context, *ListRequest) (*ConfigurationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Configurations not implemented")
+}
+func (UnimplementedSimpleFlagsServer) Variables(context.Context, *ListRequest) (*VariablesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Variables not implemented")
+}
+func (UnimplementedSimpleFlagsServer) Stream(*StreamRequest, SimpleFlags_StreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedSimpleFlagsServer) mustEmbedUnimplementedSimpleFlagsServer() {}
+
+// UnsafeSimpleFlagsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SimpleFlagsServer will
+// result in compilation errors.
+type UnsafeSimpleFlagsServer interface {
+	mustEmbedUnimplementedSimpleFlagsServer()
+}
+
+func RegisterSimpleFlagsServer(s grpc.ServiceRegistrar, srv SimpleFlagsServer) {
+	s.RegisterService(&SimpleFlags_ServiceDesc, srv)
+}
+
+func _SimpleFlags_Configurations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleFlagsServer).Configurations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/simpleflags.v1.SimpleFlags/Configurations",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleFlagsServer).Configurations(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleFlags_Variables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SimpleFlagsServer).Variables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/simpleflags.v1.SimpleFlags/Variables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SimpleFlagsServer).Variables(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SimpleFlags_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SimpleFlagsServer).Stream(m, &simpleFlagsStreamServer{stream})
+}
+
+type SimpleFlags_StreamServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type simpleFlagsStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *simpleFlagsStreamServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SimpleFlags_ServiceDesc is the grpc.ServiceDesc for SimpleFlags service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SimpleFlags_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "simpleflags.v1.SimpleFlags",
+	HandlerType: (*SimpleFlagsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Configurations",
+			Handler:    _SimpleFlags_Configurations_Handler,
+		},
+		{
+			MethodName: "Variables",
+			Handler:    _SimpleFlags_Variables_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _SimpleFlags_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "simpleflags.proto",
+}
@@ -0,0 +1,149 @@
+package simple
+
+import (
+	"encoding/json"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"strconv"
+	"sync"
+)
+
+// ResyncEvent is sent by the server when it cannot replay events
+// from the Last-Event-ID requested on reconnect
+const ResyncEvent = "resync"
+
+// versioned holds the fields needed for gap detection,
+// it matches both flag and variable payloads
+type versioned struct {
+	Identifier string
+	Version    int64
+}
+
+// tracker follows event ids and versions seen by the connector
+// in order to detect events missed while the stream was down
+type tracker struct {
+	mux         sync.Mutex
+	lastEventID string
+	connected   bool
+	versions    map[string]int64
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		versions: make(map[string]int64),
+	}
+}
+
+// LastEventID returns id of the last event received from the stream
+func (t *tracker) LastEventID() string {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.lastEventID
+}
+
+// connect returns true when the stream was connected before
+// and there is no event id to resume from
+func (t *tracker) connect() bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	reconnect := t.connected
+	t.connected = true
+	return reconnect && t.lastEventID == ""
+}
+
+// observe records the event and returns true if some events were missed
+func (t *tracker) observe(id, event, data []byte) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	gap := false
+	if len(id) > 0 {
+		gap = isSequenceGap(t.lastEventID, string(id))
+		t.lastEventID = string(id)
+	}
+
+	switch string(event) {
+	case ResyncEvent:
+		return true
+	case evaluation.CreateFlagEvent, evaluation.PatchFlagEvent:
+		gap = t.update(formatFlagKey, data) || gap
+	case evaluation.CreateVariable, evaluation.PatchVariable:
+		gap = t.update(formatVariableKey, data) || gap
+	case evaluation.DeleteFlagEvent:
+		delete(t.versions, formatFlagKey(string(data)))
+	case evaluation.DeleteVariable:
+		delete(t.versions, formatVariableKey(string(data)))
+	}
+	return gap
+}
+
+// update stores the version from data and reports a gap when
+// the version is more than one ahead of the known one
+func (t *tracker) update(key func(string) string, data []byte) bool {
+	var v versioned
+	if err := json.Unmarshal(data, &v); err != nil || v.Identifier == "" {
+		return false
+	}
+	k := key(v.Identifier)
+	known, ok := t.versions[k]
+	if ok && v.Version <= known {
+		return false
+	}
+	t.versions[k] = v.Version
+	return ok && known > 0 && v.Version > known+1
+}
+
+// seen records versions returned by the REST api
+func (t *tracker) seen(key func(string) string, items ...versioned) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for _, item := range items {
+		k := key(item.Identifier)
+		if item.Version > t.versions[k] {
+			t.versions[k] = item.Version
+		}
+	}
+}
+
+// dispatch passes stream event to the updater, pings are reported as heartbeats,
+// it asks for resync when some events were missed
+func dispatch(t *tracker, updater connector.Updater, id, event, data []byte) {
+	if string(event) == PingEvent {
+		connector.Heartbeat(updater)
+		return
+	}
+	gap := t.observe(id, event, data)
+	if string(event) != ResyncEvent {
+		updater.OnEvent(&connector.Msg{
+			ID:    id,
+			Event: event,
+			Data:  data,
+		})
+	}
+	if gap {
+		log.Infof("missed events detected on the stream, last event id %s", t.LastEventID())
+		updater.OnResync()
+	}
+}
+
+// isSequenceGap is true when both ids are numeric and some ids in between are missing
+func isSequenceGap(last, next string) bool {
+	l, err := strconv.ParseUint(last, 10, 64)
+	if err != nil {
+		return false
+	}
+	n, err := strconv.ParseUint(next, 10, 64)
+	if err != nil {
+		return false
+	}
+	return n > l+1
+}
+
+func formatFlagKey(identifier string) string {
+	return "flag__" + identifier
+}
+
+func formatVariableKey(identifier string) string {
+	return "variable__" + identifier
+}
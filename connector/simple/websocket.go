@@ -0,0 +1,148 @@
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"github.com/simpleflags/golang-server-sdk/backoff"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamTransport selects how HttpConnector receives change events
+type StreamTransport string
+
+const (
+	// SSETransport receives events as server sent events, it is the default
+	SSETransport StreamTransport = "sse"
+	// WebSocketTransport receives events over websocket, for networks
+	// which buffer or cut long living http responses
+	WebSocketTransport StreamTransport = "websocket"
+)
+
+// wsFrame is a single event sent by the server as websocket text message
+type wsFrame struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// payload returns data in the same form as sse event data,
+// JSON strings like deleted identifiers are unquoted
+func (f wsFrame) payload() []byte {
+	var s string
+	if len(f.Data) > 0 && f.Data[0] == '"' && json.Unmarshal(f.Data, &s) == nil {
+		return []byte(s)
+	}
+	return f.Data
+}
+
+// websocketURL returns stream endpoint with ws or wss scheme
+func websocketURL(streamURL string) string {
+	u := streamURL + "/stream"
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		return "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		return "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	return u
+}
+
+// dialer returns websocket dialer using proxy and TLS settings of the http transport
+func (c simpleFlagsConfig) dialer() *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            c.proxyFunc(),
+		TLSClientConfig:  c.tlsConfig(),
+		HandshakeTimeout: 10 * time.Second,
+	}
+	if transport, ok := c.client().Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+		dialer.NetDialContext = transport.DialContext
+	}
+	return dialer
+}
+
+// subscribeWebSocket reads events from websocket until the connection breaks.
+// Client pings the server every read timeout / 3 and expects some traffic
+// or pong within the read timeout.
+func (f *HttpConnector) subscribeWebSocket(ctx context.Context, updater connector.Updater, reconnect *backoff.Backoff) error {
+	headers := http.Header{}
+	f.config.setHeaders(f.apiKey, headers)
+	if id := f.tracker.LastEventID(); id != "" {
+		headers.Set("Last-Event-ID", id)
+	}
+
+	conn, resp, err := f.config.dialer().DialContext(ctx, websocketURL(f.config.streamURL), headers)
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reconnect.Reset()
+	updater.OnConnect()
+	if f.tracker.connect() {
+		// nothing to resume from, events could be lost
+		updater.OnResync()
+	}
+
+	timeout := f.config.streamReadTimeout
+	extend := func() {
+		if timeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+	}
+	extend()
+	conn.SetPongHandler(func(string) error {
+		extend()
+		return nil
+	})
+	conn.SetPingHandler(func(data string) error {
+		extend()
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		var ping <-chan time.Time
+		if timeout > 0 {
+			ticker := time.NewTicker(timeout / 3)
+			defer ticker.Stop()
+			ping = ticker.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				// unblock the reader
+				_ = conn.Close()
+				return
+			case <-done:
+				return
+			case <-ping:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		extend()
+		var frame wsFrame
+		if err := json.Unmarshal(message, &frame); err != nil {
+			continue
+		}
+		dispatch(f.tracker, updater, []byte(frame.ID), []byte(frame.Event), frame.payload())
+	}
+}
@@ -0,0 +1,89 @@
+package simple
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"go.uber.org/atomic"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebSocketURL(t *testing.T) {
+	for in, expected := range map[string]string{
+		"https://stream.example/api": "wss://stream.example/api/stream",
+		"http://localhost:1325/api":  "ws://localhost:1325/api/stream",
+	} {
+		if got := websocketURL(in); got != expected {
+			t.Fatalf("%s: expected %s, got %s", in, expected, got)
+		}
+	}
+}
+
+func TestWebSocketStreamDeliversAndReconnects(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	lastEventIDs := make(chan string, 10)
+	connections := atomic.NewInt64(0)
+	closed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stream" || r.Header.Get("API-Key") != "key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if connections.Inc() == 1 {
+			// deliver one event, then drop the connection
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"1","event":"delete-flag","data":"dark_mode"}`))
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"2","event":"delete-flag","data":"beta"}`))
+		// wait until the client closes the connection
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	conn := NewHttpConnector("key", WithStreamURL(server.URL), WithStreamTransport(WebSocketTransport),
+		WithStreamBackoff(time.Millisecond, time.Millisecond*5), WithStreamReadTimeout(time.Second*5))
+	updater := newRecordingUpdater()
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := conn.Stream(ctx, updater); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, func() bool { return updater.events.Load() == 2 }, "events were not delivered")
+	if updater.connects.Load() != 2 || updater.disconnects.Load() != 1 {
+		t.Fatalf("expected 2 connects and 1 disconnect, got %d and %d",
+			updater.connects.Load(), updater.disconnects.Load())
+	}
+	if id := <-lastEventIDs; id != "" {
+		t.Fatalf("expected fresh first connection, got last event id %q", id)
+	}
+	if id := <-lastEventIDs; id != "1" {
+		t.Fatalf("expected reconnect to resume from 1, got %q", id)
+	}
+	if conn.LastEventID() != "2" {
+		t.Fatalf("expected last event id 2, got %q", conn.LastEventID())
+	}
+
+	cancel()
+	select {
+	case <-closed:
+	case <-time.After(time.Second * 2):
+		t.Fatal("cancelled stream did not close the connection")
+	}
+	time.Sleep(time.Millisecond * 50)
+	if updater.disconnects.Load() != 1 {
+		t.Fatalf("cancelled stream reported as drop, %d disconnects", updater.disconnects.Load())
+	}
+}
@@ -0,0 +1,58 @@
+package connector
+
+import (
+	"context"
+	"github.com/simpleflags/evaluation"
+)
+
+// StaticConnector serves fixed set of configurations and variables,
+// for example defaults embedded into the binary
+type StaticConnector struct {
+	configurations evaluation.Configurations
+	variables      []evaluation.Variable
+}
+
+func NewStaticConnector(configurations evaluation.Configurations, variables []evaluation.Variable) StaticConnector {
+	return StaticConnector{
+		configurations: configurations,
+		variables:      variables,
+	}
+}
+
+func (s StaticConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	wanted := set(identifiers)
+	configurations := make(evaluation.Configurations, 0, len(s.configurations))
+	for _, config := range s.configurations {
+		if len(wanted) == 0 || wanted[config.Identifier] {
+			configurations = append(configurations, config)
+		}
+	}
+	return configurations, nil
+}
+
+func (s StaticConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	wanted := set(identifiers)
+	variables := make([]evaluation.Variable, 0, len(s.variables))
+	for _, variable := range s.variables {
+		if len(wanted) == 0 || wanted[variable.Identifier] {
+			variables = append(variables, variable)
+		}
+	}
+	return variables, nil
+}
+
+func (s StaticConnector) Stream(ctx context.Context, updater Updater) error {
+	return ErrStreamNotSupported
+}
+
+func (s StaticConnector) Close() error {
+	return nil
+}
+
+func set(identifiers []string) map[string]bool {
+	m := make(map[string]bool, len(identifiers))
+	for _, identifier := range identifiers {
+		m[identifier] = true
+	}
+	return m
+}
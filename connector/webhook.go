@@ -0,0 +1,219 @@
+package connector
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SignatureHeader carries hex encoded HMAC-SHA256 of "timestamp.body" signed with the webhook secret
+	SignatureHeader = "X-SimpleFlags-Signature"
+	// TimestampHeader carries unix time in seconds when the webhook was sent
+	TimestampHeader = "X-SimpleFlags-Timestamp"
+)
+
+// WebhookEvent is body of webhook request, the same event that is sent over stream
+type WebhookEvent struct {
+	ID    string          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// WebhookConnector receives changes pushed by the server as webhook requests
+// and serves reads from backing source. It implements http.Handler and should
+// be mounted on the route configured as webhook url.
+type WebhookConnector struct {
+	source  Connector
+	secret  []byte
+	window  time.Duration
+	now     func() time.Time
+	mux     sync.RWMutex
+	updater Updater
+	// streams counts Stream calls, so a finished stream does not
+	// unregister updater of the stream which replaced it
+	streams uint64
+	// seen maps signatures of accepted requests to the time their timestamp
+	// leaves the window, replays are rejected until then
+	seen map[string]time.Time
+}
+
+var _ Connector = &WebhookConnector{}
+var _ http.Handler = &WebhookConnector{}
+
+// WebhookOption is used for advanced webhook configuration
+type WebhookOption func(w *WebhookConnector)
+
+// WithTimestampWindow sets how old webhook requests are accepted, default is five minutes
+func WithTimestampWindow(window time.Duration) WebhookOption {
+	return func(w *WebhookConnector) {
+		w.window = window
+	}
+}
+
+// NewWebhookConnector creates webhook receiver verifying requests with secret
+// and reading flags and variables from source, the secret cannot be empty
+func NewWebhookConnector(source Connector, secret string, options ...WebhookOption) (*WebhookConnector, error) {
+	if secret == "" {
+		return nil, ErrEmptySecret
+	}
+	w := &WebhookConnector{
+		source: source,
+		secret: []byte(secret),
+		window: time.Minute * 5,
+		now:    time.Now,
+		seen:   make(map[string]time.Time),
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w, nil
+}
+
+func (w *WebhookConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	return w.source.Configurations(ctx, identifiers...)
+}
+
+func (w *WebhookConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	return w.source.Variables(ctx, identifiers...)
+}
+
+// Stream registers updater which receives accepted webhook events.
+// Webhooks have no connection, so client is reported connected until ctx is done.
+func (w *WebhookConnector) Stream(ctx context.Context, updater Updater) error {
+	w.mux.Lock()
+	w.updater = updater
+	w.streams++
+	stream := w.streams
+	w.mux.Unlock()
+
+	updater.OnConnect()
+	go func() {
+		<-ctx.Done()
+		w.mux.Lock()
+		if w.streams == stream {
+			w.updater = nil
+		}
+		w.mux.Unlock()
+	}()
+	return nil
+}
+
+func (w *WebhookConnector) Close() error {
+	return w.source.Close()
+}
+
+// ServeHTTP verifies and accepts single webhook event
+func (w *WebhookConnector) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(rw, r.Body, 1<<20))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	signature, err := w.verify(r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body)
+	if err != nil {
+		log.Errorf("webhook rejected: %v", err)
+		http.Error(rw, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Event == "" {
+		w.forget(signature)
+		http.Error(rw, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	w.mux.RLock()
+	updater := w.updater
+	w.mux.RUnlock()
+	if updater == nil {
+		// sender retries later with the same signature
+		w.forget(signature)
+		http.Error(rw, "stream not started", http.StatusServiceUnavailable)
+		return
+	}
+
+	updater.OnEvent(&Msg{
+		ID:    []byte(event.ID),
+		Event: []byte(event.Event),
+		Data:  payload(event.Data),
+	})
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// verify checks signature and rejects requests outside the window or seen before.
+// Signature of the verified request is reserved until its timestamp leaves the window,
+// callers forget it when the request is not accepted.
+func (w *WebhookConnector) verify(timestamp, signature string, body []byte) (string, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errors.New("invalid timestamp")
+	}
+	now := w.now()
+	sent := time.Unix(seconds, 0)
+	if sent.Before(now.Add(-w.window)) || sent.After(now.Add(w.window)) {
+		return "", errors.New("timestamp outside of window")
+	}
+
+	expected := Sign(w.secret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return "", errors.New("invalid signature")
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	for sig, expires := range w.seen {
+		if expires.Before(now) {
+			delete(w.seen, sig)
+		}
+	}
+	if _, ok := w.seen[expected]; ok {
+		return "", errors.New("replayed request")
+	}
+	w.seen[expected] = sent.Add(w.window)
+	return expected, nil
+}
+
+// forget releases signature of the request which was not accepted, so it can be retried
+func (w *WebhookConnector) forget(signature string) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	delete(w.seen, signature)
+}
+
+// Sign returns hex encoded HMAC-SHA256 of "timestamp.body", senders use it
+// to fill SignatureHeader
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// payload returns data as sent over sse, JSON strings like deleted identifiers are unquoted
+func payload(data json.RawMessage) []byte {
+	var s string
+	if len(data) > 0 && data[0] == '"' && json.Unmarshal(data, &s) == nil {
+		return []byte(s)
+	}
+	return data
+}
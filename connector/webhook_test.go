@@ -0,0 +1,185 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// countingUpdater counts events passed by the connector
+type countingUpdater struct {
+	events []*Msg
+}
+
+func (u *countingUpdater) OnConnect()       {}
+func (u *countingUpdater) OnDisconnect()    {}
+func (u *countingUpdater) OnEvent(msg *Msg) { u.events = append(u.events, msg) }
+func (u *countingUpdater) OnResync()        {}
+
+const testSecret = "secret"
+
+var webhookBody = []byte(`{"id":"1","event":"delete-flag","data":"dark_mode"}`)
+
+func newTestWebhook(t *testing.T, now *time.Time) *WebhookConnector {
+	t.Helper()
+	w, err := NewWebhookConnector(&stubConnector{}, testSecret, WithTimestampWindow(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return *now }
+	return w
+}
+
+func deliver(w http.Handler, sent time.Time, secret string, body []byte) int {
+	timestamp := strconv.FormatInt(sent.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign([]byte(secret), timestamp, body))
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookRejectsEmptySecret(t *testing.T) {
+	if _, err := NewWebhookConnector(&stubConnector{}, ""); err != ErrEmptySecret {
+		t.Fatalf("expected ErrEmptySecret, got %v", err)
+	}
+}
+
+func TestWebhookVerifiesSignature(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := newTestWebhook(t, &now)
+	updater := &countingUpdater{}
+	if err := w.Stream(context.Background(), updater); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := deliver(w, now, "other", webhookBody); code != http.StatusUnauthorized {
+		t.Fatalf("expected unauthorized for wrong secret, got %d", code)
+	}
+	if code := deliver(w, now, testSecret, webhookBody); code != http.StatusNoContent {
+		t.Fatalf("expected accepted event, got %d", code)
+	}
+	if len(updater.events) != 1 || string(updater.events[0].Data) != "dark_mode" {
+		t.Fatalf("unexpected events %+v", updater.events)
+	}
+}
+
+func TestWebhookWindow(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := newTestWebhook(t, &now)
+	if err := w.Stream(context.Background(), &countingUpdater{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := deliver(w, now.Add(-time.Minute*2), testSecret, webhookBody); code != http.StatusUnauthorized {
+		t.Fatalf("expected old request to be rejected, got %d", code)
+	}
+	if code := deliver(w, now.Add(time.Minute*2), testSecret, webhookBody); code != http.StatusUnauthorized {
+		t.Fatalf("expected future request to be rejected, got %d", code)
+	}
+}
+
+func TestWebhookRejectsReplays(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := newTestWebhook(t, &now)
+	updater := &countingUpdater{}
+	if err := w.Stream(context.Background(), updater); err != nil {
+		t.Fatal(err)
+	}
+
+	// signed with a timestamp ahead of the receiver clock
+	sent := now.Add(time.Second * 50)
+	if code := deliver(w, sent, testSecret, webhookBody); code != http.StatusNoContent {
+		t.Fatalf("expected accepted event, got %d", code)
+	}
+	if code := deliver(w, sent, testSecret, webhookBody); code != http.StatusUnauthorized {
+		t.Fatalf("expected replay to be rejected, got %d", code)
+	}
+
+	// the request is still within the window a minute later
+	now = now.Add(time.Minute + time.Second*10)
+	if code := deliver(w, sent, testSecret, webhookBody); code != http.StatusUnauthorized {
+		t.Fatalf("expected replay to be rejected until timestamp leaves the window, got %d", code)
+	}
+	if len(updater.events) != 1 {
+		t.Fatalf("expected single event, got %d", len(updater.events))
+	}
+}
+
+func TestWebhookRetryAfterUnavailable(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := newTestWebhook(t, &now)
+
+	if code := deliver(w, now, testSecret, webhookBody); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected unavailable before stream starts, got %d", code)
+	}
+	updater := &countingUpdater{}
+	if err := w.Stream(context.Background(), updater); err != nil {
+		t.Fatal(err)
+	}
+	if code := deliver(w, now, testSecret, webhookBody); code != http.StatusNoContent {
+		t.Fatalf("expected retry to be accepted, got %d", code)
+	}
+	if len(updater.events) != 1 {
+		t.Fatalf("expected single event, got %d", len(updater.events))
+	}
+}
+
+func TestWebhookRetryAfterInvalidEvent(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := newTestWebhook(t, &now)
+	if err := w.Stream(context.Background(), &countingUpdater{}); err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"id":"1"}`)
+	if code := deliver(w, now, testSecret, body); code != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %d", code)
+	}
+	if _, ok := w.seen[Sign([]byte(testSecret), strconv.FormatInt(now.Unix(), 10), body)]; ok {
+		t.Fatal("rejected request must not be recorded as seen")
+	}
+}
+
+func TestWebhookRestartedStreamKeepsUpdater(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	w := newTestWebhook(t, &now)
+
+	first, cancel := context.WithCancel(context.Background())
+	if err := w.Stream(first, &countingUpdater{}); err != nil {
+		t.Fatal(err)
+	}
+	updater := &countingUpdater{}
+	second, stop := context.WithCancel(context.Background())
+	defer stop()
+	if err := w.Stream(second, updater); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	// give cleanup of the first stream time to run
+	time.Sleep(time.Millisecond * 50)
+
+	if code := deliver(w, now, testSecret, webhookBody); code != http.StatusNoContent {
+		t.Fatalf("expected restarted stream to accept events, got %d", code)
+	}
+	if len(updater.events) != 1 {
+		t.Fatalf("expected event on restarted stream, got %d", len(updater.events))
+	}
+
+	stop()
+	deadline := time.Now().Add(time.Second)
+	for i := 1; ; i++ {
+		if deliver(w, now.Add(time.Second*time.Duration(i)), testSecret, webhookBody) == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected unavailable after the last stream is done")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
@@ -1,6 +1,7 @@
 package sfsdk
 
 import (
+	"context"
 	"errors"
 	"github.com/simpleflags/evaluation"
 	"github.com/simpleflags/golang-server-sdk/client"
@@ -30,10 +31,11 @@ func InitWithConnector(connector connector.Connector, options ...client.ConfigOp
 	return err
 }
 
-func WaitForInitialization() {
+func WaitForInitialization() client.InitSource {
 	if defaultClient != nil {
-		defaultClient.WaitForInitialization()
+		return defaultClient.WaitForInitialization()
 	}
+	return client.InitNone
 }
 
 func Evaluate(feature string, target evaluation.Target) evaluation.Evaluation {
@@ -43,6 +45,49 @@ func Evaluate(feature string, target evaluation.Target) evaluation.Evaluation {
 	return evaluation.Evaluation{}
 }
 
+func EvaluateCtx(ctx context.Context, feature string, target evaluation.Target) evaluation.Evaluation {
+	if defaultClient != nil {
+		return defaultClient.EvaluateCtx(ctx, feature, target)
+	}
+	return evaluation.Evaluation{}
+}
+
+func EvaluateFromContext(ctx context.Context, feature string) evaluation.Evaluation {
+	if defaultClient != nil {
+		return defaultClient.EvaluateFromContext(ctx, feature)
+	}
+	return evaluation.Evaluation{}
+}
+
+func EvaluateAll(target evaluation.Target) map[string]evaluation.Evaluation {
+	if defaultClient != nil {
+		return defaultClient.EvaluateAll(target)
+	}
+	return map[string]evaluation.Evaluation{}
+}
+
+func SetOffline(offline bool) {
+	if defaultClient != nil {
+		defaultClient.SetOffline(offline)
+	}
+}
+
+func Status() client.Status {
+	if defaultClient != nil {
+		return defaultClient.Status()
+	}
+	return client.Status{}
+}
+
+// Redact returns target without private attributes, nothing is returned
+// before the client is initialized because private attributes are not known
+func Redact(target evaluation.Target) evaluation.Target {
+	if defaultClient != nil {
+		return defaultClient.Redact(target)
+	}
+	return evaluation.Target{}
+}
+
 func Close() error {
 	if defaultClient != nil {
 		return defaultClient.Close()
@@ -0,0 +1,44 @@
+// Package metrics defines measurements reported by the SDK. Implementations
+// live in subpackages, so their dependencies are linked only when used.
+package metrics
+
+import (
+	"github.com/simpleflags/golang-server-sdk/repository"
+	"time"
+)
+
+// Repository layers reported with Recorder.Lookup
+const (
+	LayerCache   = repository.LayerCache
+	LayerStorage = repository.LayerStorage
+)
+
+// Recorder receives SDK measurements, implementations must be safe for concurrent use
+type Recorder interface {
+	// Evaluation is called for every evaluated flag, variation is "true" or "false"
+	// for boolean flags and empty otherwise
+	Evaluation(feature, variation string, duration time.Duration)
+	// Lookup is called when repository looks up flag or variable in the layer
+	Lookup(layer string, hit bool)
+	// Poll is called when pull of flags and variables finished
+	Poll(duration time.Duration, err error)
+	// StreamReconnect is called when stream connected again after it was lost
+	StreamReconnect()
+	// UpdaterEvent is called for every stream event, dropped events were not applied
+	UpdaterEvent(event string, dropped bool)
+	// DataAge is called periodically with time since data was last synchronized
+	DataAge(age time.Duration)
+}
+
+// Noop discards all measurements, it is used when metrics are disabled
+type Noop struct{}
+
+var _ Recorder = Noop{}
+var _ repository.LookupRecorder = Noop{}
+
+func (Noop) Evaluation(feature, variation string, duration time.Duration) {}
+func (Noop) Lookup(layer string, hit bool)                                {}
+func (Noop) Poll(duration time.Duration, err error)                       {}
+func (Noop) StreamReconnect()                                             {}
+func (Noop) UpdaterEvent(event string, dropped bool)                      {}
+func (Noop) DataAge(age time.Duration)                                    {}
@@ -0,0 +1,130 @@
+// Package otel reports SDK metrics with OpenTelemetry
+package otel
+
+import (
+	"context"
+	"github.com/simpleflags/golang-server-sdk/metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/metric/instrument/syncfloat64"
+	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/metric/unit"
+	"go.uber.org/atomic"
+	"time"
+)
+
+// InstrumentationName is name of the meter used by the SDK
+const InstrumentationName = "github.com/simpleflags/golang-server-sdk"
+
+// Recorder implements metrics.Recorder with OpenTelemetry instruments
+type Recorder struct {
+	evaluations        syncint64.Counter
+	evaluationDuration syncfloat64.Histogram
+	lookups            syncint64.Counter
+	pollDuration       syncfloat64.Histogram
+	pollErrors         syncint64.Counter
+	reconnects         syncint64.Counter
+	updaterEvents      syncint64.Counter
+	// dataAge is observed by the meter on collection
+	dataAge *atomic.Float64
+}
+
+var _ metrics.Recorder = &Recorder{}
+
+// New creates recorder with instruments of the provider,
+// use global.MeterProvider() to report with other service metrics
+func New(provider metric.MeterProvider) (*Recorder, error) {
+	meter := provider.Meter(InstrumentationName)
+	r := &Recorder{dataAge: atomic.NewFloat64(0)}
+
+	var err error
+	if r.evaluations, err = meter.SyncInt64().Counter("simpleflags.evaluations",
+		instrument.WithDescription("Number of flag evaluations by flag and variation")); err != nil {
+		return nil, err
+	}
+	if r.evaluationDuration, err = meter.SyncFloat64().Histogram("simpleflags.evaluation.duration",
+		instrument.WithDescription("Time spent evaluating flags"), instrument.WithUnit(unit.Milliseconds)); err != nil {
+		return nil, err
+	}
+	if r.lookups, err = meter.SyncInt64().Counter("simpleflags.repository.lookups",
+		instrument.WithDescription("Repository lookups of flags and variables by layer and result")); err != nil {
+		return nil, err
+	}
+	if r.pollDuration, err = meter.SyncFloat64().Histogram("simpleflags.poll.duration",
+		instrument.WithDescription("Time spent pulling flags and variables"), instrument.WithUnit(unit.Milliseconds)); err != nil {
+		return nil, err
+	}
+	if r.pollErrors, err = meter.SyncInt64().Counter("simpleflags.poll.errors",
+		instrument.WithDescription("Number of failed pulls")); err != nil {
+		return nil, err
+	}
+	if r.reconnects, err = meter.SyncInt64().Counter("simpleflags.stream.reconnects",
+		instrument.WithDescription("Number of times stream connected again after it was lost")); err != nil {
+		return nil, err
+	}
+	if r.updaterEvents, err = meter.SyncInt64().Counter("simpleflags.updater.events",
+		instrument.WithDescription("Stream events by event type and result")); err != nil {
+		return nil, err
+	}
+
+	dataAge, err := meter.AsyncFloat64().Gauge("simpleflags.data.age",
+		instrument.WithDescription("Time since flags were last synchronized"), instrument.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	err = meter.RegisterCallback([]instrument.Asynchronous{dataAge}, func(ctx context.Context) {
+		dataAge.Observe(ctx, r.dataAge.Load())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Evaluation counts evaluations, variation attribute is left out when it is empty
+func (r *Recorder) Evaluation(feature, variation string, duration time.Duration) {
+	ctx := context.Background()
+	attributes := []attribute.KeyValue{attribute.String("feature", feature)}
+	if variation != "" {
+		attributes = append(attributes, attribute.String("variation", variation))
+	}
+	r.evaluations.Add(ctx, 1, attributes...)
+	r.evaluationDuration.Record(ctx, milliseconds(duration))
+}
+
+func (r *Recorder) Lookup(layer string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	r.lookups.Add(context.Background(), 1, attribute.String("layer", layer), attribute.String("result", result))
+}
+
+func (r *Recorder) Poll(duration time.Duration, err error) {
+	ctx := context.Background()
+	r.pollDuration.Record(ctx, milliseconds(duration))
+	if err != nil {
+		r.pollErrors.Add(ctx, 1)
+	}
+}
+
+func (r *Recorder) StreamReconnect() {
+	r.reconnects.Add(context.Background(), 1)
+}
+
+func (r *Recorder) UpdaterEvent(event string, dropped bool) {
+	result := "processed"
+	if dropped {
+		result = "dropped"
+	}
+	r.updaterEvents.Add(context.Background(), 1, attribute.String("event", event), attribute.String("result", result))
+}
+
+func (r *Recorder) DataAge(age time.Duration) {
+	r.dataAge.Store(age.Seconds())
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,81 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metrictest"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	provider, exporter := metrictest.NewTestMeterProvider()
+	r, err := New(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Evaluation("dark_mode", "true", time.Millisecond)
+	r.Evaluation("dark_mode", "true", time.Millisecond)
+	r.Evaluation("banner", "", time.Millisecond)
+	r.Lookup("cache", true)
+	r.Poll(time.Second, nil)
+	r.Poll(time.Second, errors.New("api down"))
+	r.StreamReconnect()
+	r.UpdaterEvent("patch-flag", true)
+	r.DataAge(time.Minute)
+
+	if err := exporter.Collect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	counters := []struct {
+		name       string
+		attributes []attribute.KeyValue
+		expected   int64
+	}{
+		{"simpleflags.evaluations", []attribute.KeyValue{attribute.String("feature", "dark_mode"), attribute.String("variation", "true")}, 2},
+		{"simpleflags.evaluations", []attribute.KeyValue{attribute.String("feature", "banner")}, 1},
+		{"simpleflags.repository.lookups", []attribute.KeyValue{attribute.String("layer", "cache"), attribute.String("result", "hit")}, 1},
+		{"simpleflags.poll.errors", nil, 1},
+		{"simpleflags.stream.reconnects", nil, 1},
+		{"simpleflags.updater.events", []attribute.KeyValue{attribute.String("event", "patch-flag"), attribute.String("result", "dropped")}, 1},
+	}
+	for _, counter := range counters {
+		record, err := exporter.GetByNameAndAttributes(counter.name, counter.attributes)
+		if err != nil {
+			t.Fatalf("%s %v: %v", counter.name, counter.attributes, err)
+		}
+		if got := record.Sum.AsInt64(); got != counter.expected {
+			t.Fatalf("%s %v: expected %d, got %d", counter.name, counter.attributes, counter.expected, got)
+		}
+	}
+
+	// evaluation without variation has no variation attribute
+	for _, record := range exporter.GetRecords() {
+		if record.InstrumentName != "simpleflags.evaluations" {
+			continue
+		}
+		for _, attr := range record.Attributes {
+			if attr.Key == "variation" && attr.Value.AsString() == "" {
+				t.Fatalf("empty variation attribute reported: %v", record.Attributes)
+			}
+		}
+	}
+
+	duration, err := exporter.GetByName("simpleflags.poll.duration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration.Count != 2 {
+		t.Fatalf("expected 2 recorded polls, got %d", duration.Count)
+	}
+	age, err := exporter.GetByName("simpleflags.data.age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := age.LastValue.AsFloat64(); got != 60 {
+		t.Fatalf("expected data age of 60s, got %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+// Package prometheus reports SDK metrics to Prometheus
+package prometheus
+
+import (
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/simpleflags/golang-server-sdk/metrics"
+	"time"
+)
+
+const namespace = "simpleflags"
+
+// Recorder implements metrics.Recorder with Prometheus collectors
+type Recorder struct {
+	evaluations        *prom.CounterVec
+	evaluationDuration prom.Histogram
+	lookups            *prom.CounterVec
+	pollDuration       prom.Histogram
+	pollErrors         prom.Counter
+	reconnects         prom.Counter
+	updaterEvents      *prom.CounterVec
+	dataAge            prom.Gauge
+}
+
+var _ metrics.Recorder = &Recorder{}
+
+// New creates recorder and registers its collectors with registerer,
+// use prom.DefaultRegisterer to expose them with other service metrics
+func New(registerer prom.Registerer) (*Recorder, error) {
+	r := &Recorder{
+		evaluations: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "evaluations_total",
+			Help:      "Number of flag evaluations by flag and variation.",
+		}, []string{"feature", "variation"}),
+		evaluationDuration: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "evaluation_duration_seconds",
+			Help:      "Time spent evaluating flags.",
+			Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01},
+		}),
+		lookups: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_lookups_total",
+			Help:      "Repository lookups of flags and variables by layer and result.",
+		}, []string{"layer", "result"}),
+		pollDuration: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "poll_duration_seconds",
+			Help:      "Time spent pulling flags and variables.",
+			Buckets:   prom.DefBuckets,
+		}),
+		pollErrors: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "poll_errors_total",
+			Help:      "Number of failed pulls.",
+		}),
+		reconnects: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "stream_reconnects_total",
+			Help:      "Number of times stream connected again after it was lost.",
+		}),
+		updaterEvents: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "updater_events_total",
+			Help:      "Stream events by event type and result.",
+		}, []string{"event", "result"}),
+		dataAge: prom.NewGauge(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "data_age_seconds",
+			Help:      "Time since flags were last synchronized.",
+		}),
+	}
+
+	collectors := []prom.Collector{r.evaluations, r.evaluationDuration, r.lookups, r.pollDuration,
+		r.pollErrors, r.reconnects, r.updaterEvents, r.dataAge}
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) Evaluation(feature, variation string, duration time.Duration) {
+	r.evaluations.WithLabelValues(feature, variation).Inc()
+	r.evaluationDuration.Observe(duration.Seconds())
+}
+
+func (r *Recorder) Lookup(layer string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	r.lookups.WithLabelValues(layer, result).Inc()
+}
+
+func (r *Recorder) Poll(duration time.Duration, err error) {
+	r.pollDuration.Observe(duration.Seconds())
+	if err != nil {
+		r.pollErrors.Inc()
+	}
+}
+
+func (r *Recorder) StreamReconnect() {
+	r.reconnects.Inc()
+}
+
+func (r *Recorder) UpdaterEvent(event string, dropped bool) {
+	result := "processed"
+	if dropped {
+		result = "dropped"
+	}
+	r.updaterEvents.WithLabelValues(event, result).Inc()
+}
+
+func (r *Recorder) DataAge(age time.Duration) {
+	r.dataAge.Set(age.Seconds())
+}
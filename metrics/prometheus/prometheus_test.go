@@ -0,0 +1,67 @@
+package prometheus
+
+import (
+	"errors"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	registry := prom.NewRegistry()
+	r, err := New(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Evaluation("dark_mode", "true", time.Millisecond)
+	r.Evaluation("dark_mode", "true", time.Millisecond)
+	r.Evaluation("banner", "", time.Millisecond)
+	r.Lookup("cache", true)
+	r.Lookup("storage", false)
+	r.Poll(time.Second, nil)
+	r.Poll(time.Second, errors.New("api down"))
+	r.StreamReconnect()
+	r.UpdaterEvent("patch-flag", false)
+	r.UpdaterEvent("patch-flag", true)
+	r.DataAge(time.Minute)
+
+	values := map[string]struct {
+		collector prom.Collector
+		expected  float64
+	}{
+		"evaluations true":  {r.evaluations.WithLabelValues("dark_mode", "true"), 2},
+		"evaluations empty": {r.evaluations.WithLabelValues("banner", ""), 1},
+		"cache hits":        {r.lookups.WithLabelValues("cache", "hit"), 1},
+		"storage misses":    {r.lookups.WithLabelValues("storage", "miss"), 1},
+		"poll errors":       {r.pollErrors, 1},
+		"reconnects":        {r.reconnects, 1},
+		"processed events":  {r.updaterEvents.WithLabelValues("patch-flag", "processed"), 1},
+		"dropped events":    {r.updaterEvents.WithLabelValues("patch-flag", "dropped"), 1},
+		"data age":          {r.dataAge, 60},
+	}
+	for name, value := range values {
+		if got := testutil.ToFloat64(value.collector); got != value.expected {
+			t.Fatalf("%s: expected %v, got %v", name, value.expected, got)
+		}
+	}
+
+	count, err := testutil.GatherAndCount(registry, "simpleflags_evaluation_duration_seconds", "simpleflags_poll_duration_seconds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("expected both histograms to be exposed, got %d", count)
+	}
+}
+
+func TestRecorderRegistersOnce(t *testing.T) {
+	registry := prom.NewRegistry()
+	if _, err := New(registry); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(registry); err == nil {
+		t.Fatal("expected error registering collectors twice")
+	}
+}
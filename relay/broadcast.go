@@ -0,0 +1,112 @@
+package relay
+
+import (
+	"strconv"
+	"sync"
+)
+
+// event is a change event sent to downstream streams
+type event struct {
+	id   uint64
+	name string
+	data []byte
+}
+
+// broadcaster fans out events to all subscribed streams and keeps
+// recent history, so reconnecting streams can resume from Last-Event-ID
+type broadcaster struct {
+	mux         sync.Mutex
+	seq         uint64
+	size        int
+	history     []event
+	subscribers map[chan event]struct{}
+}
+
+func newBroadcaster(size int) *broadcaster {
+	return &broadcaster{
+		size:        size,
+		history:     make([]event, 0, size),
+		subscribers: make(map[chan event]struct{}),
+	}
+}
+
+// publish sends event to every subscriber, subscribers which can't keep up
+// are dropped and resume from history when they reconnect
+func (b *broadcaster) publish(name string, data []byte) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.seq++
+	ev := event{id: b.seq, name: name, data: data}
+	if len(b.history) == b.size && b.size > 0 {
+		b.history = b.history[1:]
+	}
+	if b.size > 0 {
+		b.history = append(b.history, ev)
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns channel with new events and events missed since lastEventID.
+// When history doesn't go back far enough resync is true and the stream
+// should tell downstream to reload everything.
+func (b *broadcaster) subscribe(lastEventID string) (ch chan event, replay []event, resync bool) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	ch = make(chan event, 64)
+	b.subscribers[ch] = struct{}{}
+
+	if lastEventID == "" {
+		return ch, nil, false
+	}
+	last, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || last > b.seq {
+		// id from another relay instance or before restart
+		return ch, nil, true
+	}
+	if last == b.seq {
+		return ch, nil, false
+	}
+	if len(b.history) == 0 || b.history[0].id > last+1 {
+		return ch, nil, true
+	}
+	for _, ev := range b.history {
+		if ev.id > last {
+			replay = append(replay, ev)
+		}
+	}
+	return ch, replay, false
+}
+
+// unsubscribe removes the channel, it is safe to call for dropped subscribers
+func (b *broadcaster) unsubscribe(ch chan event) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// lastID returns id of the latest event
+func (b *broadcaster) lastID() uint64 {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.seq
+}
+
+// count returns number of connected streams
+func (b *broadcaster) count() int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return len(b.subscribers)
+}
@@ -0,0 +1,121 @@
+package relay
+
+import (
+	"encoding/json"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/client"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"github.com/simpleflags/golang-server-sdk/repository"
+)
+
+// environment holds single upstream client and serves its data to downstream SDKs
+type environment struct {
+	sdkKey      string
+	client      client.Client
+	repository  repository.Repository
+	broadcaster *broadcaster
+}
+
+var _ repository.Callback = &environment{}
+
+func newEnvironment(sdkKey string, config config) (*environment, error) {
+	cache, err := repository.NewLruCache(config.cacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := config.connector(sdkKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// cache only speeds up lookups, all items are kept in storage
+	// so evicted ones are still served to downstream SDKs
+	storage := repository.NewMemoryStorage()
+	env := &environment{
+		sdkKey:      sdkKey,
+		repository:  repository.New(cache, repository.WithStorage(storage)),
+		broadcaster: newBroadcaster(config.historySize),
+	}
+
+	options := append([]client.ConfigOption{}, config.clientOptions...)
+	options = append(options, client.WithCache(cache), client.WithStorage(storage), client.WithCallback(env))
+	env.client, err = client.NewWithConnector(conn, options...)
+	if err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// OnFlagStored publishes stored flag to downstream streams
+func (e *environment) OnFlagStored(identifier string) {
+	config, err := e.repository.GetConfiguration(identifier)
+	if err != nil {
+		return
+	}
+	e.publish(evaluation.PatchFlagEvent, config)
+}
+
+// OnFlagDeleted publishes deleted flag identifier to downstream streams
+func (e *environment) OnFlagDeleted(identifier string) {
+	e.broadcaster.publish(evaluation.DeleteFlagEvent, []byte(identifier))
+}
+
+// OnVariableStored publishes stored variable to downstream streams
+func (e *environment) OnVariableStored(identifier string) {
+	variable, err := e.repository.GetVariable(identifier)
+	if err != nil {
+		return
+	}
+	e.publish(evaluation.PatchVariable, variable)
+}
+
+// OnVariableDeleted publishes deleted variable identifier to downstream streams
+func (e *environment) OnVariableDeleted(identifier string) {
+	e.broadcaster.publish(evaluation.DeleteVariable, []byte(identifier))
+}
+
+func (e *environment) publish(name string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Errorf("relay could not encode %s event: %v", name, err)
+		return
+	}
+	e.broadcaster.publish(name, data)
+}
+
+// ready reports if the upstream data was loaded, a failed first pull
+// leaves the client initialized but without any data
+func (e *environment) ready() bool {
+	return e.client.InitSource() == client.InitServer
+}
+
+func (e *environment) configurations(identifiers []string) []evaluation.Configuration {
+	if len(identifiers) == 0 {
+		return e.repository.Configurations()
+	}
+	configurations := make([]evaluation.Configuration, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		if config, err := e.repository.GetConfiguration(identifier); err == nil {
+			configurations = append(configurations, config)
+		}
+	}
+	return configurations
+}
+
+func (e *environment) variables(identifiers []string) []evaluation.Variable {
+	if len(identifiers) == 0 {
+		return e.repository.Variables()
+	}
+	variables := make([]evaluation.Variable, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		if variable, err := e.repository.GetVariable(identifier); err == nil {
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+func (e *environment) close() error {
+	return e.client.Close()
+}
@@ -0,0 +1,78 @@
+package relay
+
+import (
+	"github.com/simpleflags/golang-server-sdk/client"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"github.com/simpleflags/golang-server-sdk/connector/simple"
+	"time"
+)
+
+// Option is used for advanced relay configuration
+type Option func(c *config)
+
+type config struct {
+	upstreamOptions   []simple.Option
+	clientOptions     []client.ConfigOption
+	connectorFactory  func(sdkKey string) (connector.Connector, error)
+	heartbeatInterval time.Duration
+	historySize       int
+	cacheSize         int
+}
+
+// WithUpstreamOptions sets options of HttpConnector used to reach SimpleFlags
+func WithUpstreamOptions(options ...simple.Option) Option {
+	return func(c *config) {
+		c.upstreamOptions = append(c.upstreamOptions, options...)
+	}
+}
+
+// WithClientOptions sets options of upstream clients
+func WithClientOptions(options ...client.ConfigOption) Option {
+	return func(c *config) {
+		c.clientOptions = append(c.clientOptions, options...)
+	}
+}
+
+// WithConnectorFactory replaces HttpConnector used for upstream connections
+func WithConnectorFactory(factory func(sdkKey string) (connector.Connector, error)) Option {
+	return func(c *config) {
+		c.connectorFactory = factory
+	}
+}
+
+// WithHeartbeatInterval sets how often downstream streams get keep-alive comments
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.heartbeatInterval = interval
+	}
+}
+
+// WithHistorySize sets how many events are kept per environment for resuming streams
+func WithHistorySize(size int) Option {
+	return func(c *config) {
+		c.historySize = size
+	}
+}
+
+// WithCacheSize sets how many flags and variables are cached per environment,
+// items over the size are still served from memory
+func WithCacheSize(size int) Option {
+	return func(c *config) {
+		c.cacheSize = size
+	}
+}
+
+func newDefaultConfig() config {
+	return config{
+		heartbeatInterval: time.Second * 30,
+		historySize:       1000,
+		cacheSize:         100000,
+	}
+}
+
+func (c config) connector(sdkKey string) (connector.Connector, error) {
+	if c.connectorFactory != nil {
+		return c.connectorFactory(sdkKey)
+	}
+	return simple.NewHttpConnector(sdkKey, c.upstreamOptions...), nil
+}
@@ -0,0 +1,184 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/simpleflags/golang-server-sdk/connector/simple"
+	"github.com/simpleflags/golang-server-sdk/log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment describes one upstream environment served by the relay
+type Environment struct {
+	// SDKKey used to connect to SimpleFlags
+	SDKKey string
+	// APIKeys accepted from downstream SDKs, SDKKey is used when empty
+	APIKeys []string
+}
+
+// Relay holds one upstream connection per environment and serves flags to
+// downstream SDKs with the same api HttpConnector uses. Downstream SDKs only
+// need simple.WithBaseURL and simple.WithStreamURL pointed to the relay.
+type Relay struct {
+	config       config
+	environments []*environment
+	keys         map[string]*environment
+}
+
+// New connects to all environments
+func New(environments []Environment, options ...Option) (*Relay, error) {
+	config := newDefaultConfig()
+	for _, option := range options {
+		option(&config)
+	}
+
+	r := &Relay{
+		config: config,
+		keys:   make(map[string]*environment),
+	}
+	for _, e := range environments {
+		if e.SDKKey == "" {
+			_ = r.Close()
+			return nil, errors.New("environment SDK key cannot be empty")
+		}
+		env, err := newEnvironment(e.SDKKey, config)
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("environment %s: %w", e.SDKKey, err)
+		}
+		r.environments = append(r.environments, env)
+
+		keys := e.APIKeys
+		if len(keys) == 0 {
+			keys = []string{e.SDKKey}
+		}
+		for _, key := range keys {
+			r.keys[key] = env
+		}
+	}
+	return r, nil
+}
+
+// Handler returns http handler serving /configs, /vars, /stream and /health
+func (r *Relay) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/configs", r.authorized(r.configs))
+	mux.HandleFunc("/vars", r.authorized(r.vars))
+	mux.HandleFunc("/stream", r.authorized(r.stream))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// Close disconnects from all environments
+func (r *Relay) Close() error {
+	var firstErr error
+	for _, env := range r.environments {
+		if err := env.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (r *Relay) authorized(handler func(env *environment, w http.ResponseWriter, req *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		env, ok := r.keys[req.Header.Get("API-Key")]
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler(env, w, req)
+	}
+}
+
+func (r *Relay) configs(env *environment, w http.ResponseWriter, req *http.Request) {
+	if !env.ready() {
+		http.Error(w, "flags not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, env.configurations(identifiers(req)))
+}
+
+func (r *Relay) vars(env *environment, w http.ResponseWriter, req *http.Request) {
+	if !env.ready() {
+		http.Error(w, "flags not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, env.variables(identifiers(req)))
+}
+
+// stream serves server sent events to downstream SDK
+func (r *Relay) stream(env *environment, w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch, replay, resync := env.broadcaster.subscribe(req.Header.Get("Last-Event-ID"))
+	defer env.broadcaster.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if resync {
+		writeEvent(w, event{id: env.broadcaster.lastID(), name: simple.ResyncEvent})
+	}
+	for _, ev := range replay {
+		writeEvent(w, ev)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(r.config.heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				// too slow, downstream resumes from history on reconnect
+				return
+			}
+			writeEvent(w, ev)
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+func identifiers(req *http.Request) []string {
+	value := req.URL.Query().Get("identifiers")
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Errorf("relay could not write response: %v", err)
+	}
+}
+
+func writeEvent(w http.ResponseWriter, ev event) {
+	_, _ = fmt.Fprintf(w, "id: %s\nevent: %s\n", strconv.FormatUint(ev.id, 10), ev.name)
+	for _, line := range strings.Split(string(ev.data), "\n") {
+		_, _ = fmt.Fprintf(w, "data: %s\n", line)
+	}
+	_, _ = fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,160 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"github.com/simpleflags/golang-server-sdk/client"
+	"github.com/simpleflags/golang-server-sdk/connector"
+	"go.uber.org/atomic"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// gatedConnector serves data or fails with err once release is closed
+type gatedConnector struct {
+	release        chan struct{}
+	configurations evaluation.Configurations
+	variables      []evaluation.Variable
+	err            error
+	// pulls counts finished variable reads, the last step of a pull
+	pulls *atomic.Int64
+}
+
+func newGatedConnector(configurations evaluation.Configurations, variables []evaluation.Variable, err error) *gatedConnector {
+	return &gatedConnector{
+		release:        make(chan struct{}),
+		configurations: configurations,
+		variables:      variables,
+		err:            err,
+		pulls:          atomic.NewInt64(0),
+	}
+}
+
+func (g *gatedConnector) Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error) {
+	select {
+	case <-g.release:
+		if g.err != nil {
+			return evaluation.Configurations{}, g.err
+		}
+		return g.configurations, nil
+	case <-ctx.Done():
+		return evaluation.Configurations{}, ctx.Err()
+	}
+}
+
+func (g *gatedConnector) Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error) {
+	defer g.pulls.Inc()
+	if g.err != nil {
+		return []evaluation.Variable{}, g.err
+	}
+	return g.variables, nil
+}
+
+func (g *gatedConnector) Stream(ctx context.Context, updater connector.Updater) error {
+	return connector.ErrStreamNotSupported
+}
+
+func (g *gatedConnector) Close() error {
+	return nil
+}
+
+func get(handler http.Handler, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("API-Key", "key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTestRelay(t *testing.T, upstream connector.Connector, options ...Option) *Relay {
+	t.Helper()
+	relay, err := New([]Environment{{SDKKey: "key"}}, append([]Option{
+		WithConnectorFactory(func(string) (connector.Connector, error) { return upstream, nil }),
+		WithClientOptions(client.WithStreamEnabled(false)),
+	}, options...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = relay.Close()
+	})
+	return relay
+}
+
+// eventually fails the test when condition is not met within two seconds
+func eventually(t *testing.T, condition func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 2)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestRelayUnavailableUntilInitialized(t *testing.T) {
+	upstream := newGatedConnector(evaluation.Configurations{
+		{Identifier: "a"}, {Identifier: "b"}, {Identifier: "c"},
+	}, nil, nil)
+	// smaller than the number of flags, evicted ones must still be served
+	handler := newTestRelay(t, upstream, WithCacheSize(1)).Handler()
+
+	for _, path := range []string{"/configs", "/vars"} {
+		if code := get(handler, path).Code; code != http.StatusServiceUnavailable {
+			t.Fatalf("expected %s to be unavailable before upstream pull, got %d", path, code)
+		}
+	}
+
+	close(upstream.release)
+	eventually(t, func() bool { return get(handler, "/configs").Code == http.StatusOK }, "relay did not become available")
+
+	var configurations evaluation.Configurations
+	if err := json.NewDecoder(get(handler, "/configs").Body).Decode(&configurations); err != nil {
+		t.Fatal(err)
+	}
+	if len(configurations) != 3 {
+		t.Fatalf("expected all flags, got %+v", configurations)
+	}
+}
+
+func TestRelayUnavailableAfterFailedPull(t *testing.T) {
+	upstream := newGatedConnector(nil, nil, errors.New("upstream down"))
+	close(upstream.release)
+	handler := newTestRelay(t, upstream).Handler()
+
+	eventually(t, func() bool { return upstream.pulls.Load() > 0 }, "upstream was not pulled")
+	for _, path := range []string{"/configs", "/vars"} {
+		if code := get(handler, path).Code; code != http.StatusServiceUnavailable {
+			t.Fatalf("expected %s to be unavailable after failed pull, got %d", path, code)
+		}
+	}
+}
+
+func TestRelayPublishesOnlyChanges(t *testing.T) {
+	upstream := newGatedConnector(
+		evaluation.Configurations{{Identifier: "beta", Version: 1, Rules: []evaluation.Rule{{Expression: "beta_users"}}}},
+		[]evaluation.Variable{{Identifier: "beta_users"}}, nil)
+	close(upstream.release)
+	// without puller the client pulls on start and when it goes online again
+	relay := newTestRelay(t, upstream, WithClientOptions(client.WithPullerEnabled(false)))
+	env := relay.environments[0]
+
+	eventually(t, env.ready, "relay did not load data")
+	published := env.broadcaster.lastID()
+	if published == 0 {
+		t.Fatal("expected the first pull to be published")
+	}
+
+	pulls := upstream.pulls.Load()
+	env.client.SetOffline(true)
+	env.client.SetOffline(false)
+	eventually(t, func() bool { return upstream.pulls.Load() > pulls }, "upstream was not pulled again")
+	if last := env.broadcaster.lastID(); last != published {
+		t.Fatalf("expected pull without changes to publish nothing, got %d events", last-published)
+	}
+}
@@ -39,7 +39,8 @@ func NewLruCache(size int) (LRUCache, error) {
 // Returns true if an eviction occurred.
 func (lru LRUCache) Set(key interface{}, value interface{}) (evicted bool) {
 	add := lru.Cache.Add(key, value)
-	log.Debugf("cache value changed for key %s with value %v", key, value)
+	// values are not logged, they may hold personal data used in rules
+	log.Debugf("cache value changed for key %s", key)
 	return add
 }
 
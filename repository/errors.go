@@ -9,4 +9,8 @@ var (
 	ErrFeatureConfigNotFound = errors.New("feature config not found")
 	// ErrSegmentNotFound ...
 	ErrSegmentNotFound = errors.New("target group not found")
+	// ErrInvalidSnapshot is returned when bundle is malformed, of unknown version or its checksum does not match
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	// ErrStorageNotListable is returned when storage does not implement Lister
+	ErrStorageNotListable = errors.New("storage can not list keys")
 )
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
@@ -13,6 +14,8 @@ type FileStorage struct {
 	mux  sync.RWMutex
 }
 
+var _ Lister = &FileStorage{}
+
 func NewFileStorage(path string) (FileStorage, error) {
 	err := os.MkdirAll(path, 0777)
 	if err != nil {
@@ -53,8 +56,33 @@ func (f *FileStorage) Set(key string, value interface{}) error {
 	return ioutil.WriteFile(fullpath, bytes, 0777)
 }
 
-func (f *FileStorage) Remove(s string) error {
-	return nil
+func (f *FileStorage) Remove(key string) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	err := os.Remove(filepath.Join(f.path, key+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Keys returns keys of all stored items
+func (f *FileStorage) Keys() ([]string, error) {
+	f.mux.RLock()
+	defer f.mux.RUnlock()
+
+	files, err := ioutil.ReadDir(f.path)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
+			keys = append(keys, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+	return keys, nil
 }
 
 func (f *FileStorage) List() []interface{} {
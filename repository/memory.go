@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+)
+
+// MemoryStorage keeps items in memory without size limit, for example to back
+// a bounded cache so evicted items are still served. Items are stored encoded,
+// so callers never share values with the storage.
+type MemoryStorage struct {
+	mux   sync.RWMutex
+	items map[string][]byte
+}
+
+var _ Storage = &MemoryStorage{}
+var _ Lister = &MemoryStorage{}
+
+// NewMemoryStorage creates empty storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		items: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryStorage) Get(key string, output interface{}) error {
+	m.mux.RLock()
+	data, ok := m.items[key]
+	m.mux.RUnlock()
+	if !ok {
+		return os.ErrNotExist
+	}
+	return json.Unmarshal(data, output)
+}
+
+func (m *MemoryStorage) Set(key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.items[key] = data
+	return nil
+}
+
+func (m *MemoryStorage) Remove(key string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+// Keys returns keys of all stored items
+func (m *MemoryStorage) Keys() ([]string, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	keys := make([]string, 0, len(m.items))
+	for key := range m.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *MemoryStorage) List() []interface{} {
+	return nil
+}
@@ -1,9 +1,15 @@
 package repository
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/simpleflags/evaluation"
 	"github.com/simpleflags/golang-server-sdk/log"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // Callback provides events when repository data being modified
@@ -19,6 +25,13 @@ type Repository struct {
 	cache    Cache
 	storage  Storage
 	callback Callback
+	syncs    *syncLog
+	stats    *stats
+	// stored tracks keys written to storage, so items can be listed
+	// even when storage does not implement Lister
+	stored *keySet
+	// ctx bounds storage lookups, nil when lookups are not bounded
+	ctx context.Context
 }
 
 type Option func(r *Repository)
@@ -29,6 +42,13 @@ func WithStorage(storage Storage) Option {
 	}
 }
 
+// WithLookupRecorder reports cache and storage lookups, for example to metrics
+func WithLookupRecorder(recorder LookupRecorder) Option {
+	return func(r *Repository) {
+		r.stats.recorder = recorder
+	}
+}
+
 func WithCallback(callback Callback) Option {
 	return func(r *Repository) {
 		r.callback = callback
@@ -38,7 +58,10 @@ func WithCallback(callback Callback) Option {
 // New repository with only cache capability
 func New(cache Cache, options ...Option) Repository {
 	r := Repository{
-		cache: cache,
+		cache:  cache,
+		syncs:  newSyncLog(),
+		stats:  &stats{},
+		stored: newKeySet(),
 	}
 
 	for _, option := range options {
@@ -48,18 +71,47 @@ func New(cache Cache, options ...Option) Repository {
 	return r
 }
 
+// WithContext returns repository sharing data with r whose storage lookups give up
+// when ctx is done. Storage has to implement ContextStorage to stop a lookup in progress.
+func (r Repository) WithContext(ctx context.Context) Repository {
+	r.ctx = ctx
+	return r
+}
+
+// load reads the key from storage within ctx given by WithContext
+func (r Repository) load(key string, output interface{}) error {
+	if r.ctx == nil {
+		return r.storage.Get(key, output)
+	}
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if storage, ok := r.storage.(ContextStorage); ok {
+		return storage.GetContext(r.ctx, key, output)
+	}
+	return r.storage.Get(key, output)
+}
+
 func (r Repository) getConfigurationAndCache(identifier string, cacheable bool) (evaluation.Configuration, error) {
 	flagKey := formatFlagKey(identifier)
 	flag, ok := r.cache.Get(flagKey)
+	if cacheable {
+		r.stats.cache(ok)
+	}
 	if ok {
 		return flag.(evaluation.Configuration), nil
 	}
 
 	if r.storage != nil {
 		var flag evaluation.Configuration
-		err := r.storage.Get(flagKey, &flag)
-		if err == nil && cacheable {
-			r.cache.Set(flagKey, flag)
+		err := r.load(flagKey, &flag)
+		if cacheable {
+			r.stats.storage(err == nil)
+		}
+		if err == nil {
+			if cacheable {
+				r.cache.Set(flagKey, flag)
+			}
 			return flag, nil
 		}
 	}
@@ -74,15 +126,23 @@ func (r Repository) GetConfiguration(identifier string) (evaluation.Configuratio
 func (r Repository) getVariableAndCache(identifier string, cacheable bool) (evaluation.Variable, error) {
 	variableKey := formatVariableKey(identifier)
 	variable, ok := r.cache.Get(variableKey)
+	if cacheable {
+		r.stats.cache(ok)
+	}
 	if ok {
 		return variable.(evaluation.Variable), nil
 	}
 
 	if r.storage != nil {
 		var variable evaluation.Variable
-		err := r.storage.Get(variableKey, &variable)
-		if err == nil && cacheable {
-			r.cache.Set(variableKey, variable)
+		err := r.load(variableKey, &variable)
+		if cacheable {
+			r.stats.storage(err == nil)
+		}
+		if err == nil {
+			if cacheable {
+				r.cache.Set(variableKey, variable)
+			}
 			return variable, nil
 		}
 	}
@@ -104,6 +164,7 @@ func (r Repository) SetConfiguration(config *evaluation.Configuration) {
 		if err := r.storage.Set(flagKey, *config); err != nil {
 			log.Errorf("error while storing the flag %s into repository", config.Identifier)
 		}
+		r.stored.add(flagKey)
 		r.cache.Remove(flagKey)
 	} else {
 		r.cache.Set(flagKey, *config)
@@ -114,13 +175,18 @@ func (r Repository) SetConfiguration(config *evaluation.Configuration) {
 	}
 }
 
-// SetVariable places a variable in the repository with the new value
+// SetVariable places a variable in the repository with the new value,
+// the same variable stored again is ignored and not reported to the callback
 func (r Repository) SetVariable(variable *evaluation.Variable) {
+	if r.isVariableUnchanged(variable) {
+		return
+	}
 	variableKey := formatVariableKey(variable.Identifier)
 	if r.storage != nil {
 		if err := r.storage.Set(variableKey, *variable); err != nil {
 			log.Errorf("error while storing the variable %s into repository", variable.Identifier)
 		}
+		r.stored.add(variableKey)
 		r.cache.Remove(variableKey)
 	} else {
 		r.cache.Set(variableKey, *variable)
@@ -140,6 +206,7 @@ func (r Repository) DeleteConfiguration(identifier string) {
 			log.Errorf("error while removing flag %s from repository", identifier)
 		}
 	}
+	r.stored.remove(flagKey)
 	// remove from cache
 	r.cache.Remove(flagKey)
 	if r.callback != nil {
@@ -156,6 +223,7 @@ func (r Repository) DeleteVariable(identifier string) {
 			log.Errorf("error while removing target group %s from repository", identifier)
 		}
 	}
+	r.stored.remove(groupKey)
 	// remove from cache
 	r.cache.Remove(groupKey)
 	if r.callback != nil {
@@ -163,6 +231,68 @@ func (r Repository) DeleteVariable(identifier string) {
 	}
 }
 
+// keys returns keys with the prefix held in cache or storage. Items kept only
+// in storage are listed when storage implements Lister or were stored by this repository.
+func (r Repository) keys(prefix string) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if strings.HasPrefix(key, prefix) && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range r.cache.Keys() {
+		if k, ok := key.(string); ok {
+			add(k)
+		}
+	}
+	if r.storage == nil {
+		return keys
+	}
+	for _, key := range r.stored.list() {
+		add(key)
+	}
+	if lister, ok := r.storage.(Lister); ok {
+		stored, err := lister.Keys()
+		if err != nil {
+			log.Errorf("error while listing repository storage: %v", err)
+		}
+		for _, key := range stored {
+			add(key)
+		}
+	}
+	return keys
+}
+
+// Configurations returns all flags held in cache or storage
+func (r Repository) Configurations() []evaluation.Configuration {
+	configurations := make([]evaluation.Configuration, 0)
+	for _, key := range r.keys(flagKeyPrefix) {
+		if config, err := r.getConfigurationAndCache(strings.TrimPrefix(key, flagKeyPrefix), false); err == nil {
+			configurations = append(configurations, config)
+		}
+	}
+	return configurations
+}
+
+// Variables returns all variables held in cache or storage
+func (r Repository) Variables() []evaluation.Variable {
+	variables := make([]evaluation.Variable, 0)
+	for _, key := range r.keys(variableKeyPrefix) {
+		if variable, err := r.getVariableAndCache(strings.TrimPrefix(key, variableKeyPrefix), false); err == nil {
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+// Counts returns number of flags and variables held in cache or storage
+func (r Repository) Counts() (flags int, variables int) {
+	return len(r.keys(flagKeyPrefix)), len(r.keys(variableKeyPrefix))
+}
+
 func (r Repository) isFlagOutdated(config *evaluation.Configuration) bool {
 	oldFlag, err := r.getConfigurationAndCache(config.Identifier, false)
 	if err != nil {
@@ -172,15 +302,68 @@ func (r Repository) isFlagOutdated(config *evaluation.Configuration) bool {
 	return oldFlag.Version >= config.Version
 }
 
+// isVariableUnchanged reports if the same variable is already stored. Variables have
+// no version, so they are compared by their JSON form, which storage also holds.
+func (r Repository) isVariableUnchanged(variable *evaluation.Variable) bool {
+	oldVariable, err := r.getVariableAndCache(variable.Identifier, false)
+	if err != nil {
+		return false
+	}
+	oldData, err := json.Marshal(oldVariable)
+	if err != nil {
+		return false
+	}
+	data, err := json.Marshal(variable)
+	return err == nil && bytes.Equal(oldData, data)
+}
+
 // Close all resources
 func (r Repository) Close() {
 
 }
 
+const (
+	flagKeyPrefix     = "flag__"
+	variableKeyPrefix = "variable__"
+)
+
 func formatFlagKey(identifier interface{}) string {
-	return "flag__" + identifier.(string)
+	return flagKeyPrefix + identifier.(string)
 }
 
 func formatVariableKey(identifier interface{}) string {
-	return "variable__" + identifier.(string)
+	return variableKeyPrefix + identifier.(string)
+}
+
+// keySet is a concurrent set of repository keys
+type keySet struct {
+	mux  sync.RWMutex
+	keys map[string]struct{}
+}
+
+func newKeySet() *keySet {
+	return &keySet{keys: make(map[string]struct{})}
+}
+
+func (s *keySet) add(key string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.keys[key] = struct{}{}
+}
+
+func (s *keySet) remove(key string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.keys, key)
+}
+
+func (s *keySet) list() []string {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"github.com/simpleflags/evaluation"
+	"testing"
+)
+
+func newTestRepository(t *testing.T, size int, options ...Option) Repository {
+	t.Helper()
+	cache, err := NewLruCache(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(cache, options...)
+}
+
+func TestRepositoryListsStoredItems(t *testing.T) {
+	repo := newTestRepository(t, 1, WithStorage(NewMemoryStorage()))
+	for _, identifier := range []string{"a", "b", "c"} {
+		repo.SetConfiguration(&evaluation.Configuration{Identifier: identifier, Version: 1})
+		repo.SetVariable(&evaluation.Variable{Identifier: identifier})
+	}
+	// fill the cache so stored items get evicted from it
+	if _, err := repo.GetConfiguration("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(repo.Configurations()); got != 3 {
+		t.Fatalf("expected 3 flags, got %d", got)
+	}
+	if got := len(repo.Variables()); got != 3 {
+		t.Fatalf("expected 3 variables, got %d", got)
+	}
+	if flags, variables := repo.Counts(); flags != 3 || variables != 3 {
+		t.Fatalf("expected 3 flags and 3 variables, got %d and %d", flags, variables)
+	}
+
+	repo.DeleteConfiguration("b")
+	if flags, _ := repo.Counts(); flags != 2 {
+		t.Fatalf("expected deleted flag to be gone, got %d flags", flags)
+	}
+}
+
+func TestRepositoryListsSharedStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	writer := newTestRepository(t, 10, WithStorage(storage))
+	writer.SetConfiguration(&evaluation.Configuration{Identifier: "dark_mode", Version: 1})
+
+	reader := newTestRepository(t, 10, WithStorage(storage))
+	configurations := reader.Configurations()
+	if len(configurations) != 1 || configurations[0].Identifier != "dark_mode" {
+		t.Fatalf("expected flag written by another repository, got %+v", configurations)
+	}
+}
+
+func TestRepositoryKeepsNewerStoredFlag(t *testing.T) {
+	repo := newTestRepository(t, 10, WithStorage(NewMemoryStorage()))
+	repo.SetConfiguration(&evaluation.Configuration{Identifier: "dark_mode", Version: 2})
+	repo.SetConfiguration(&evaluation.Configuration{Identifier: "dark_mode", Version: 1})
+
+	config, err := repo.GetConfiguration("dark_mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Version != 2 {
+		t.Fatalf("expected outdated flag to be ignored, got version %d", config.Version)
+	}
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/simpleflags/evaluation"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is format version of bundles written by WriteSnapshot
+const SnapshotVersion = 1
+
+// Source provides all flags and variables, every connector.Connector is a Source
+type Source interface {
+	Configurations(ctx context.Context, identifiers ...string) (evaluation.Configurations, error)
+	Variables(ctx context.Context, identifiers ...string) ([]evaluation.Variable, error)
+}
+
+// Lister is implemented by storages which can enumerate stored keys
+type Lister interface {
+	Keys() ([]string, error)
+}
+
+// Snapshot is point in time copy of all flags and variables
+type Snapshot struct {
+	CreatedAt      time.Time
+	Configurations evaluation.Configurations
+	Variables      []evaluation.Variable
+}
+
+// bundle is the file format, checksum covers exact bytes of data
+type bundle struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Checksum  string          `json:"checksum"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type bundleData struct {
+	Configurations evaluation.Configurations `json:"configurations"`
+	Variables      []evaluation.Variable     `json:"variables"`
+}
+
+// NewSnapshot creates snapshot with items sorted by identifier,
+// so the same data always produces the same checksum
+func NewSnapshot(configurations evaluation.Configurations, variables []evaluation.Variable) Snapshot {
+	configs := append(evaluation.Configurations{}, configurations...)
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Identifier < configs[j].Identifier
+	})
+	vars := append([]evaluation.Variable{}, variables...)
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Identifier < vars[j].Identifier
+	})
+	return Snapshot{
+		CreatedAt:      time.Now().UTC(),
+		Configurations: configs,
+		Variables:      vars,
+	}
+}
+
+// ExportSource takes snapshot of everything the source serves
+func ExportSource(ctx context.Context, source Source) (Snapshot, error) {
+	configurations, err := source.Configurations(ctx)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("export flags: %w", err)
+	}
+	variables, err := source.Variables(ctx)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("export variables: %w", err)
+	}
+	return NewSnapshot(configurations, variables), nil
+}
+
+// ExportStorage takes snapshot of storage, it has to implement Lister
+func ExportStorage(storage Storage) (Snapshot, error) {
+	lister, ok := storage.(Lister)
+	if !ok {
+		return Snapshot{}, fmt.Errorf("%w: %T", ErrStorageNotListable, storage)
+	}
+	keys, err := lister.Keys()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	configurations := make(evaluation.Configurations, 0)
+	variables := make([]evaluation.Variable, 0)
+	for _, key := range keys {
+		switch {
+		case strings.HasPrefix(key, flagKeyPrefix):
+			var config evaluation.Configuration
+			if err := storage.Get(key, &config); err != nil {
+				return Snapshot{}, fmt.Errorf("export %s: %w", key, err)
+			}
+			configurations = append(configurations, config)
+		case strings.HasPrefix(key, variableKeyPrefix):
+			var variable evaluation.Variable
+			if err := storage.Get(key, &variable); err != nil {
+				return Snapshot{}, fmt.Errorf("export %s: %w", key, err)
+			}
+			variables = append(variables, variable)
+		}
+	}
+	return NewSnapshot(configurations, variables), nil
+}
+
+// Snapshot takes snapshot of flags and variables held in cache
+func (r Repository) Snapshot() Snapshot {
+	return NewSnapshot(r.Configurations(), r.Variables())
+}
+
+// WriteSnapshot writes versioned bundle with sha256 checksum of the data
+func WriteSnapshot(w io.Writer, snapshot Snapshot) error {
+	data, err := json.Marshal(bundleData{
+		Configurations: snapshot.Configurations,
+		Variables:      snapshot.Variables,
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle{
+		Version:   SnapshotVersion,
+		CreatedAt: snapshot.CreatedAt,
+		Checksum:  checksum(data),
+		Data:      data,
+	})
+}
+
+// ReadSnapshot reads bundle and verifies its version and checksum
+func ReadSnapshot(r io.Reader) (Snapshot, error) {
+	var b bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if b.Version != SnapshotVersion {
+		return Snapshot{}, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, b.Version)
+	}
+	if sum := checksum(compact(b.Data)); sum != b.Checksum {
+		return Snapshot{}, fmt.Errorf("%w: checksum %s does not match %s", ErrInvalidSnapshot, sum, b.Checksum)
+	}
+
+	var data bundleData
+	if err := json.Unmarshal(b.Data, &data); err != nil {
+		return Snapshot{}, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	return Snapshot{
+		CreatedAt:      b.CreatedAt,
+		Configurations: data.Configurations,
+		Variables:      data.Variables,
+	}, nil
+}
+
+// ReadSnapshotFile reads bundle from the file
+func ReadSnapshotFile(path string) (Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer file.Close()
+	return ReadSnapshot(file)
+}
+
+// RestoreStorage writes snapshot into storage. Restore is destructive: when storage
+// implements Lister, every stored item missing in the snapshot is deleted,
+// so storage matches the snapshot.
+func (s Snapshot) RestoreStorage(storage Storage) error {
+	keep := make(map[string]bool, len(s.Configurations)+len(s.Variables))
+	for _, config := range s.Configurations {
+		key := formatFlagKey(config.Identifier)
+		if err := storage.Set(key, config); err != nil {
+			return fmt.Errorf("restore %s: %w", key, err)
+		}
+		keep[key] = true
+	}
+	for _, variable := range s.Variables {
+		key := formatVariableKey(variable.Identifier)
+		if err := storage.Set(key, variable); err != nil {
+			return fmt.Errorf("restore %s: %w", key, err)
+		}
+		keep[key] = true
+	}
+
+	lister, ok := storage.(Lister)
+	if !ok {
+		return nil
+	}
+	keys, err := lister.Keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !keep[key] {
+			if err := storage.Remove(key); err != nil {
+				return fmt.Errorf("remove %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RestoreDir writes snapshot in layout read by connector.FileConnector,
+// dir/flags/<identifier>.json and dir/variables/<identifier>.json. Restore is
+// destructive: json files of items missing in the snapshot are deleted.
+func (s Snapshot) RestoreDir(dir string) error {
+	items := make(map[string]interface{}, len(s.Configurations)+len(s.Variables))
+	for _, config := range s.Configurations {
+		items[filepath.Join("flags", config.Identifier+".json")] = config
+	}
+	for _, variable := range s.Variables {
+		items[filepath.Join("variables", variable.Identifier+".json")] = variable
+	}
+
+	for _, sub := range []string{"flags", "variables"} {
+		path := filepath.Join(dir, sub)
+		if err := os.MkdirAll(path, 0777); err != nil {
+			return err
+		}
+		// drop files of items which are not in the snapshot
+		files, err := ioutil.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			name := filepath.Join(sub, file.Name())
+			if _, ok := items[name]; !ok && filepath.Ext(name) == ".json" {
+				if err := os.Remove(filepath.Join(dir, name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for name, item := range items {
+		data, err := json.MarshalIndent(item, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// compact removes indentation added around data when the bundle was written
+func compact(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
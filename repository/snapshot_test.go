@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"github.com/simpleflags/evaluation"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testSnapshot() Snapshot {
+	return NewSnapshot(
+		evaluation.Configurations{
+			{Identifier: "new_checkout", Version: 2},
+			{Identifier: "dark_mode", Version: 1, Rules: []evaluation.Rule{{Expression: `country == "CZ"`}}},
+		},
+		[]evaluation.Variable{{Identifier: "beta_users"}},
+	)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"checksum": "sha256:`) {
+		t.Fatalf("expected sha256 checksum in bundle: %s", buf.String())
+	}
+
+	snapshot, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Configurations) != 2 || snapshot.Configurations[0].Identifier != "dark_mode" ||
+		snapshot.Configurations[0].Rules[0].Expression != `country == "CZ"` {
+		t.Fatalf("unexpected flags %+v", snapshot.Configurations)
+	}
+	if len(snapshot.Variables) != 1 || snapshot.Variables[0].Identifier != "beta_users" {
+		t.Fatalf("unexpected variables %+v", snapshot.Variables)
+	}
+}
+
+func TestSnapshotRejectsTamperedBundle(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, testSnapshot()); err != nil {
+		t.Fatal(err)
+	}
+	bundle := buf.String()
+
+	tampered := map[string]string{
+		"data":     strings.Replace(bundle, `country == \"CZ\"`, `country == \"SK\"`, 1),
+		"checksum": strings.Replace(bundle, `"sha256:`, `"sha256:00`, 1),
+		"version":  strings.Replace(bundle, `"version": 1`, `"version": 99`, 1),
+		"json":     bundle[:len(bundle)/2],
+	}
+	for name, content := range tampered {
+		if content == bundle {
+			t.Fatalf("%s: bundle was not modified", name)
+		}
+		if _, err := ReadSnapshot(strings.NewReader(content)); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Fatalf("%s: expected ErrInvalidSnapshot, got %v", name, err)
+		}
+	}
+}
+
+func TestRestoreDeletesMissingItems(t *testing.T) {
+	storage := NewMemoryStorage()
+	for _, key := range []string{formatFlagKey("removed"), formatVariableKey("removed")} {
+		if err := storage.Set(key, evaluation.Variable{Identifier: "removed"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := testSnapshot().RestoreStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := storage.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("expected only snapshot items in storage, got %v", keys)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"flags/removed.json", "variables/removed.json", "flags/README.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("{}"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := testSnapshot().RestoreDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for name, exists := range map[string]bool{
+		"flags/dark_mode.json":      true,
+		"flags/new_checkout.json":   true,
+		"variables/beta_users.json": true,
+		"flags/README.md":           true,
+		"flags/removed.json":        false,
+		"variables/removed.json":    false,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != exists {
+			t.Fatalf("%s: expected exists %v, got %v", name, exists, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import "go.uber.org/atomic"
+
+// Cache and storage layers reported to LookupRecorder
+const (
+	LayerCache   = "cache"
+	LayerStorage = "storage"
+)
+
+// LookupRecorder is notified about every lookup, metrics.Recorder implements it
+type LookupRecorder interface {
+	Lookup(layer string, hit bool)
+}
+
+// Stats counts lookups served by cache and storage
+type Stats struct {
+	CacheHits     uint64
+	CacheMisses   uint64
+	StorageHits   uint64
+	StorageMisses uint64
+}
+
+// HitRatio returns share of lookups served from cache, zero when there were none
+func (s Stats) HitRatio() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.CacheHits) / float64(total)
+}
+
+type stats struct {
+	cacheHits     atomic.Uint64
+	cacheMisses   atomic.Uint64
+	storageHits   atomic.Uint64
+	storageMisses atomic.Uint64
+	recorder      LookupRecorder
+}
+
+func (s *stats) cache(hit bool) {
+	if s.recorder != nil {
+		s.recorder.Lookup(LayerCache, hit)
+	}
+	if hit {
+		s.cacheHits.Inc()
+	} else {
+		s.cacheMisses.Inc()
+	}
+}
+
+func (s *stats) storage(hit bool) {
+	if s.recorder != nil {
+		s.recorder.Lookup(LayerStorage, hit)
+	}
+	if hit {
+		s.storageHits.Inc()
+	} else {
+		s.storageMisses.Inc()
+	}
+}
+
+// Stats returns lookup counters of flags and variables
+func (r Repository) Stats() Stats {
+	return Stats{
+		CacheHits:     r.stats.cacheHits.Load(),
+		CacheMisses:   r.stats.cacheMisses.Load(),
+		StorageHits:   r.stats.storageHits.Load(),
+		StorageMisses: r.stats.storageMisses.Load(),
+	}
+}
@@ -1,5 +1,9 @@
 package repository
 
+import (
+	"context"
+)
+
 // Storage is an interface that can be implemented in order to have control over how
 // the repository of feature toggles is persisted.
 type Storage interface {
@@ -13,3 +17,8 @@ type Storage interface {
 	// List returns a list of all feature toggles.
 	List() []interface{}
 }
+
+// ContextStorage is implemented by storages which can give up a lookup when ctx is done
+type ContextStorage interface {
+	GetContext(ctx context.Context, key string, output interface{}) error
+}
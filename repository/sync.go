@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// Sources of data recorded with MarkSynced
+const (
+	SyncPull      = "pull"
+	SyncStream    = "stream"
+	SyncBootstrap = "bootstrap"
+)
+
+// syncLog holds time of the last successful sync per source,
+// it is shared by all copies of the repository
+type syncLog struct {
+	mux   sync.RWMutex
+	times map[string]time.Time
+	now   func() time.Time
+}
+
+func newSyncLog() *syncLog {
+	return &syncLog{times: make(map[string]time.Time), now: time.Now}
+}
+
+// WithClock sets the time source of sync records, default is time.Now
+func WithClock(now func() time.Time) Option {
+	return func(r *Repository) {
+		r.syncs.now = now
+	}
+}
+
+// MarkSynced records that data from source was successfully applied now
+func (r Repository) MarkSynced(source string) {
+	r.syncs.mux.Lock()
+	defer r.syncs.mux.Unlock()
+	r.syncs.times[source] = r.syncs.now()
+}
+
+// LastSync returns time of the last successful sync from source
+func (r Repository) LastSync(source string) (time.Time, bool) {
+	r.syncs.mux.RLock()
+	defer r.syncs.mux.RUnlock()
+	t, ok := r.syncs.times[source]
+	return t, ok
+}
+
+// LastSynced returns time of the latest sync from any source, zero if there was none
+func (r Repository) LastSynced() time.Time {
+	r.syncs.mux.RLock()
+	defer r.syncs.mux.RUnlock()
+	var last time.Time
+	for _, t := range r.syncs.times {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return last
+}